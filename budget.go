@@ -0,0 +1,90 @@
+package dbinitiator
+
+import (
+	"context"
+	"sync/atomic"
+
+	"cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+// WithQueryBudget counts the statements and reads executed through db.Client from the call
+// until the test finishes, failing the test at cleanup if more than budget were executed. Use
+// it in repository tests to catch N+1 query regressions. Retries by the client are counted.
+// Only db.Client is counted: the clients returned by ReadOnlyClient and RecordingClient, and
+// MigrateUp and MigrateDown, which run migrations on their own client, are not. Subtests
+// sharing the database count each other's statements.
+func (db *SpannerDB) WithQueryBudget(tb TB, budget int) {
+	tb.Helper()
+
+	start := db.queries.Load()
+	tb.Cleanup(func() {
+		if n := db.queries.Load() - start; n > int64(budget) {
+			tb.Errorf("query budget exceeded: %d statements executed, budget is %d", n, budget)
+		}
+	})
+}
+
+// countingClientOptions returns the client options counting the statements and reads of the
+// client in count
+func countingClientOptions(count *atomic.Int64) []option.ClientOption {
+	return []option.ClientOption{
+		option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(countingUnaryInterceptor(count))),
+		option.WithGRPCDialOption(grpc.WithChainStreamInterceptor(countingStreamInterceptor(count))),
+	}
+}
+
+func countingUnaryInterceptor(count *atomic.Int64) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		count.Add(int64(countStatements(req)))
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func countingStreamInterceptor(count *atomic.Int64) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		return &countingStream{ClientStream: stream, count: count}, nil
+	}
+}
+
+// countingStream counts the statements sent on a streaming call, such as ExecuteStreamingSql
+type countingStream struct {
+	grpc.ClientStream
+	count *atomic.Int64
+}
+
+func (s *countingStream) SendMsg(m any) error {
+	s.count.Add(int64(countStatements(m)))
+
+	return s.ClientStream.SendMsg(m)
+}
+
+// countStatements returns the number of statements or reads req executes. Requests resuming
+// an interrupted stream are not counted again.
+func countStatements(req any) int {
+	switch r := req.(type) {
+	case *spannerpb.ExecuteSqlRequest:
+		if len(r.GetResumeToken()) > 0 {
+			return 0
+		}
+
+		return 1
+	case *spannerpb.ReadRequest:
+		if len(r.GetResumeToken()) > 0 {
+			return 0
+		}
+
+		return 1
+	case *spannerpb.ExecuteBatchDmlRequest:
+		return len(r.GetStatements())
+	default:
+		return 0
+	}
+}
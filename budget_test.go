@@ -0,0 +1,115 @@
+package dbinitiator
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/apiv1/spannerpb"
+)
+
+func Test_countStatements(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		req  any
+		want int
+	}{
+		{name: "query", req: &spannerpb.ExecuteSqlRequest{Sql: "SELECT 1"}, want: 1},
+		{name: "resumed query", req: &spannerpb.ExecuteSqlRequest{Sql: "SELECT 1", ResumeToken: []byte("token")}, want: 0},
+		{name: "read", req: &spannerpb.ReadRequest{Table: "Users"}, want: 1},
+		{name: "batch dml", req: &spannerpb.ExecuteBatchDmlRequest{Statements: make([]*spannerpb.ExecuteBatchDmlRequest_Statement, 3)}, want: 3},
+		{name: "commit", req: &spannerpb.CommitRequest{}, want: 0},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := countStatements(tt.req); got != tt.want {
+				t.Errorf("countStatements() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpannerDB_WithQueryBudget(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	tests := []struct {
+		name       string
+		budget     int
+		migrate    bool
+		wantFailed bool
+	}{
+		{name: "within budget", budget: 2},
+		{name: "over budget", budget: 1, wantFailed: true},
+		{name: "migrations not counted", budget: 2, migrate: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, err := container.CreateTestDatabase(ctx, t.Name())
+			if err != nil {
+				t.Fatalf("SpannerContainer.CreateTestDatabase() error = %v", err)
+			}
+			db.Cleanup(t)
+
+			// Statements before the budget starts are not counted
+			if err := db.Single().Query(ctx, spanner.Statement{SQL: "SELECT 1"}).Do(func(*spanner.Row) error { return nil }); err != nil {
+				t.Fatalf("spanner.RowIterator.Do() error = %v", err)
+			}
+
+			tb := &recordingTB{TB: t}
+			db.WithQueryBudget(tb, tt.budget)
+			if tt.migrate {
+				if err := db.MigrateUp("file://testdata/migrations"); err != nil {
+					t.Fatalf("SpannerDB.MigrateUp() error = %v", err)
+				}
+			}
+			for i := 0; i < 2; i++ {
+				if err := db.Single().Query(ctx, spanner.Statement{SQL: "SELECT 1"}).Do(func(*spanner.Row) error { return nil }); err != nil {
+					t.Fatalf("spanner.RowIterator.Do() error = %v", err)
+				}
+			}
+			tb.runCleanups()
+			if tb.failed != tt.wantFailed {
+				t.Errorf("SpannerDB.WithQueryBudget() failed = %v, want %v", tb.failed, tt.wantFailed)
+			}
+		})
+	}
+}
+
+// recordingTB records failures and cleanups instead of applying them to the test
+type recordingTB struct {
+	testing.TB
+	failed   bool
+	cleanups []func()
+}
+
+func (tb *recordingTB) Errorf(string, ...any) {
+	tb.failed = true
+}
+
+func (tb *recordingTB) Failed() bool {
+	return tb.failed
+}
+
+func (tb *recordingTB) Cleanup(f func()) {
+	tb.cleanups = append(tb.cleanups, f)
+}
+
+func (tb *recordingTB) runCleanups() {
+	for i := len(tb.cleanups) - 1; i >= 0; i-- {
+		tb.cleanups[i]()
+	}
+}
@@ -0,0 +1,229 @@
+// Command testdb manages a Spanner emulator container for local development, creating
+// migrated throwaway databases outside of go test.
+//
+// Usage:
+//
+//	testdb up                                  start the emulator container
+//	testdb down                                remove the emulator container
+//	testdb create <name> -migrations <dir>     create a database and migrate it up
+//	testdb env                                 print environment variables for clients
+//
+// The container is configured by the TESTDB_* environment variables (see ConfigFromEnv)
+// and is always reused between invocations.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	dbinitiator "github.com/cccteam/db-initiator"
+	"github.com/go-playground/errors/v5"
+)
+
+const usage = `usage: testdb <command> [arguments]
+
+commands:
+  up                              start the emulator container
+  down                            remove the emulator container
+  create <name> -migrations <dir> create a database and migrate it up
+  env                             print environment variables for clients
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// The container must outlive this process, so disable the testcontainers reaper
+	if err := os.Setenv("TESTCONTAINERS_RYUK_DISABLED", "true"); err != nil {
+		fmt.Fprintf(os.Stderr, "testdb: %s\n", err)
+		os.Exit(1)
+	}
+
+	if err := run(context.Background(), os.Args[1], os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "testdb: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, command string, args []string) error {
+	cfg, err := dbinitiator.ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	cfg.Reuse = true
+
+	switch command {
+	case "up":
+		return up(ctx, cfg)
+	case "down":
+		return down(ctx, cfg)
+	case "create":
+		return create(ctx, cfg, args)
+	case "env":
+		return env(ctx, cfg)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+
+		return errors.Newf("unknown command %q", command)
+	}
+}
+
+func up(ctx context.Context, cfg *dbinitiator.Config) error {
+	sp, err := dbinitiator.NewSpannerContainerFromConfig(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer sp.Close()
+
+	fmt.Printf("emulator running on %s\n", sp.Endpoint())
+
+	return nil
+}
+
+func down(ctx context.Context, cfg *dbinitiator.Config) error {
+	// Reusing a missing container would start one only to remove it
+	exists, err := dbinitiator.ContainerExists(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		fmt.Println("no emulator running")
+
+		return nil
+	}
+
+	sp, err := dbinitiator.NewSpannerContainerFromConfig(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := sp.Close(); err != nil {
+		return err
+	}
+
+	if err := sp.Terminate(ctx); err != nil {
+		return errors.Wrap(err, "testcontainers.Container.Terminate()")
+	}
+
+	fmt.Println("emulator removed")
+
+	return nil
+}
+
+func create(ctx context.Context, cfg *dbinitiator.Config, args []string) error {
+	name, sources, err := parseCreateArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if err := requireRunning(ctx, cfg); err != nil {
+		return err
+	}
+
+	sp, err := dbinitiator.NewSpannerContainerFromConfig(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer sp.Close()
+
+	db, err := sp.CreateDatabase(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := db.MigrateUp(sources...); err != nil {
+		return err
+	}
+
+	fmt.Println(db.DatabaseName())
+
+	return nil
+}
+
+// parseCreateArgs returns the database name and the migration sources of the create command
+func parseCreateArgs(args []string) (name string, sources []string, err error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return "", nil, errors.New("create: database name is required")
+	}
+	name = args[0]
+
+	var migrations stringsFlag
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(&migrations, "migrations", "migrations directory, may be repeated")
+	if err := fs.Parse(args[1:]); err != nil {
+		return "", nil, errors.Wrap(err, "flag.FlagSet.Parse()")
+	}
+	if fs.NArg() > 0 {
+		return "", nil, errors.Newf("create: unexpected arguments %v", fs.Args())
+	}
+
+	sources = make([]string, 0, len(migrations))
+	for _, dir := range migrations {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return "", nil, errors.Wrap(err, "filepath.Abs()")
+		}
+		sources = append(sources, "file://"+abs)
+	}
+
+	return name, sources, nil
+}
+
+func env(ctx context.Context, cfg *dbinitiator.Config) error {
+	if err := requireRunning(ctx, cfg); err != nil {
+		return err
+	}
+
+	sp, err := dbinitiator.NewSpannerContainerFromConfig(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer sp.Close()
+
+	writeEnv(os.Stdout, sp.Endpoint(), cfg)
+
+	return nil
+}
+
+// writeEnv writes the environment variables clients need to connect to the emulator at endpoint
+func writeEnv(w io.Writer, endpoint string, cfg *dbinitiator.Config) {
+	fmt.Fprintf(w, "export SPANNER_EMULATOR_HOST=%s\n", endpoint)
+	fmt.Fprintf(w, "export SPANNER_PROJECT_ID=%s\n", cfg.ProjectID)
+	fmt.Fprintf(w, "export SPANNER_INSTANCE_ID=%s\n", cfg.InstanceID)
+}
+
+// requireRunning returns an error if the emulator container does not exist, so commands
+// using it do not quietly start a new, empty one
+func requireRunning(ctx context.Context, cfg *dbinitiator.Config) error {
+	exists, err := dbinitiator.ContainerExists(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("no emulator running, start one with testdb up")
+	}
+
+	return nil
+}
+
+// stringsFlag is a flag.Value collecting repeated string flags
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+
+	return nil
+}
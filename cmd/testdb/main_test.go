@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	dbinitiator "github.com/cccteam/db-initiator"
+)
+
+func Test_parseCreateArgs(t *testing.T) {
+	t.Parallel()
+
+	users, err := filepath.Abs("migrations/users")
+	if err != nil {
+		t.Fatalf("filepath.Abs() error = %v", err)
+	}
+	orders, err := filepath.Abs("migrations/orders")
+	if err != nil {
+		t.Fatalf("filepath.Abs() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		args        []string
+		wantName    string
+		wantSources []string
+		wantErr     bool
+	}{
+		{name: "name only", args: []string{"dev"}, wantName: "dev", wantSources: []string{}},
+		{
+			name:        "repeated migrations",
+			args:        []string{"dev", "-migrations", "migrations/users", "-migrations", "migrations/orders"},
+			wantName:    "dev",
+			wantSources: []string{"file://" + users, "file://" + orders},
+		},
+		{name: "missing name", args: nil, wantErr: true},
+		{name: "flag before name", args: []string{"-migrations", "migrations/users"}, wantErr: true},
+		{name: "unknown flag", args: []string{"dev", "-seed", "1"}, wantErr: true},
+		{name: "missing flag value", args: []string{"dev", "-migrations"}, wantErr: true},
+		{name: "extra argument", args: []string{"dev", "other"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			name, sources, err := parseCreateArgs(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCreateArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if name != tt.wantName || !reflect.DeepEqual(sources, tt.wantSources) {
+				t.Errorf("parseCreateArgs() = %v, %v, want %v, %v", name, sources, tt.wantName, tt.wantSources)
+			}
+		})
+	}
+}
+
+func Test_writeEnv(t *testing.T) {
+	t.Parallel()
+
+	var b bytes.Buffer
+	writeEnv(&b, "localhost:9010", &dbinitiator.Config{ProjectID: "project", InstanceID: "instance"})
+
+	want := `export SPANNER_EMULATOR_HOST=localhost:9010
+export SPANNER_PROJECT_ID=project
+export SPANNER_INSTANCE_ID=instance
+`
+	if got := b.String(); got != want {
+		t.Errorf("writeEnv() = %q, want %q", got, want)
+	}
+}
+
+func Test_run_unknownCommand(t *testing.T) {
+	t.Parallel()
+
+	err := run(context.Background(), "start", nil)
+	if err == nil || !strings.Contains(err.Error(), `unknown command "start"`) {
+		t.Errorf("run() error = %v, want an unknown command error", err)
+	}
+}
+
+func Test_stringsFlag(t *testing.T) {
+	t.Parallel()
+
+	var s stringsFlag
+	for _, v := range []string{"a", "b"} {
+		if err := s.Set(v); err != nil {
+			t.Fatalf("stringsFlag.Set() error = %v", err)
+		}
+	}
+
+	if got, want := s.String(), "a,b"; got != want {
+		t.Errorf("stringsFlag.String() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	dbinitiator "github.com/cccteam/db-initiator"
+	"github.com/go-playground/errors/v5"
+)
+
+const fixturesTemplate = `// Code generated by testdbgen. DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+{{- range .Imports }}
+	"{{ . }}"
+{{- end }}
+)
+{{ range .Tables }}
+// {{ .GoName }} is a row of the {{ .Name }} table
+type {{ .GoName }} struct {
+{{- range .Fields }}
+	{{ .GoName }} {{ .GoType }} ` + "`spanner:\"{{ .Name }}\"`" + `
+{{- end }}
+}
+
+// Mutation returns a mutation inserting r into the {{ .Name }} table
+func (r *{{ .GoName }}) Mutation() (*spanner.Mutation, error) {
+	return spanner.InsertStruct("{{ .Name }}", r)
+}
+
+// Insert{{ .GoName }} inserts rows into the {{ .Name }} table in a single commit
+{{- if .ParentTable }}. The parent
+// rows in the {{ .ParentTable }} table must exist.
+{{- end }}
+func Insert{{ .GoName }}(ctx context.Context, client *spanner.Client, rows ...*{{ .GoName }}) error {
+	mutations := make([]*spanner.Mutation, 0, len(rows))
+	for _, r := range rows {
+		m, err := r.Mutation()
+		if err != nil {
+			return err
+		}
+		mutations = append(mutations, m)
+	}
+	_, err := client.Apply(ctx, mutations)
+
+	return err
+}
+{{ end -}}
+`
+
+// genericType holds values of column types without a more specific Go type
+const genericType = "spanner.GenericColumnValue"
+
+// mutationMethod is the method generated on row types, which fields can not be named
+const mutationMethod = "Mutation"
+
+type templateData struct {
+	Package string
+	Imports []string
+	Tables  []templateTable
+}
+
+type templateTable struct {
+	Name        string
+	GoName      string
+	ParentTable string
+	Fields      []templateField
+}
+
+type templateField struct {
+	Name   string
+	GoName string
+	GoType string
+}
+
+// generate returns the formatted source of the fixture builders for tables
+func generate(pkg string, tables []dbinitiator.Table) ([]byte, error) {
+	imports := map[string]bool{
+		"context":                     true,
+		"cloud.google.com/go/spanner": true,
+	}
+
+	data := templateData{Package: pkg}
+	for _, t := range tables {
+		tt := templateTable{Name: t.Name, GoName: goName(t.Name), ParentTable: t.ParentTable}
+		used := map[string]bool{mutationMethod: true}
+		for _, c := range t.Columns {
+			// Generated columns can not be written
+			if c.Generated {
+				continue
+			}
+
+			typ, imp := goType(c.SpannerType, c.Nullable)
+			if imp != "" {
+				imports[imp] = true
+			}
+			tt.Fields = append(tt.Fields, templateField{Name: c.Name, GoName: fieldName(c.Name, used), GoType: typ})
+		}
+		data.Tables = append(data.Tables, tt)
+	}
+
+	for imp := range imports {
+		data.Imports = append(data.Imports, imp)
+	}
+	sort.Strings(data.Imports)
+
+	tmpl, err := template.New("fixtures").Parse(fixturesTemplate)
+	if err != nil {
+		return nil, errors.Wrap(err, "template.Template.Parse()")
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return nil, errors.Wrap(err, "template.Template.Execute()")
+	}
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "format.Source()")
+	}
+
+	return src, nil
+}
+
+// goType returns the Go type used for a column of spannerType, and the package it requires
+// to be imported, if any besides spanner
+func goType(spannerType string, nullable bool) (typ, imp string) {
+	if elemType, ok := strings.CutPrefix(spannerType, "ARRAY<"); ok {
+		elemType = strings.TrimSuffix(elemType, ">")
+		if baseType(elemType) == "BYTES" {
+			return "[][]byte", ""
+		}
+		typ, _ := goType(elemType, true)
+		if typ == genericType {
+			return genericType, ""
+		}
+
+		return "[]" + typ, ""
+	}
+
+	switch baseType(spannerType) {
+	case "BOOL":
+		return nullableType("bool", "spanner.NullBool", nullable), ""
+	case "INT64":
+		return nullableType("int64", "spanner.NullInt64", nullable), ""
+	case "FLOAT64":
+		return nullableType("float64", "spanner.NullFloat64", nullable), ""
+	case "FLOAT32":
+		return nullableType("float32", "spanner.NullFloat32", nullable), ""
+	case "STRING":
+		return nullableType("string", "spanner.NullString", nullable), ""
+	case "BYTES":
+		return "[]byte", ""
+	case "JSON":
+		return "spanner.NullJSON", ""
+	case "NUMERIC":
+		if nullable {
+			return "spanner.NullNumeric", ""
+		}
+
+		return "big.Rat", "math/big"
+	case "DATE":
+		if nullable {
+			return "spanner.NullDate", ""
+		}
+
+		return "civil.Date", "cloud.google.com/go/civil"
+	case "TIMESTAMP":
+		if nullable {
+			return "spanner.NullTime", ""
+		}
+
+		return "time.Time", "time"
+	default:
+		return genericType, ""
+	}
+}
+
+func nullableType(typ, nullType string, nullable bool) string {
+	if nullable {
+		return nullType
+	}
+
+	return typ
+}
+
+// baseType strips the length from types such as STRING(MAX)
+func baseType(spannerType string) string {
+	base, _, _ := strings.Cut(spannerType, "(")
+
+	return base
+}
+
+// fieldName returns the Go field name of column, suffixed with Column while it collides with
+// a generated method or another field in used, and adds it to used
+func fieldName(column string, used map[string]bool) string {
+	name := goName(column)
+	for used[name] {
+		name += "Column"
+	}
+	used[name] = true
+
+	return name
+}
+
+// goName converts a table or column name into an exported Go identifier, e.g. user_id to UserId
+func goName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+
+	s := b.String()
+	if s == "" || !unicode.IsLetter(rune(s[0])) {
+		s = "T" + s
+	}
+
+	return s
+}
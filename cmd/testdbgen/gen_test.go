@@ -0,0 +1,128 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	dbinitiator "github.com/cccteam/db-initiator"
+)
+
+func Test_goName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "pascal case", in: "UserId", want: "UserId"},
+		{name: "snake case", in: "user_id", want: "UserId"},
+		{name: "leading digit", in: "2fa", want: "T2fa"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := goName(tt.in); got != tt.want {
+				t.Errorf("goName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_fieldName(t *testing.T) {
+	t.Parallel()
+
+	used := map[string]bool{mutationMethod: true}
+	tests := []struct {
+		column string
+		want   string
+	}{
+		{column: "UserId", want: "UserId"},
+		{column: "user_id", want: "UserIdColumn"},
+		{column: "Mutation", want: "MutationColumn"},
+		{column: "MutationColumn", want: "MutationColumnColumn"},
+	}
+	// Cases run in order, each adding to used
+	for _, tt := range tests {
+		if got := fieldName(tt.column, used); got != tt.want {
+			t.Errorf("fieldName(%q) = %v, want %v", tt.column, got, tt.want)
+		}
+	}
+}
+
+func Test_goType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		spannerType string
+		nullable    bool
+		wantType    string
+		wantImport  string
+	}{
+		{name: "INT64", spannerType: "INT64", wantType: "int64"},
+		{name: "nullable STRING", spannerType: "STRING(MAX)", nullable: true, wantType: "spanner.NullString"},
+		{name: "NUMERIC", spannerType: "NUMERIC", wantType: "big.Rat", wantImport: "math/big"},
+		{name: "DATE", spannerType: "DATE", wantType: "civil.Date", wantImport: "cloud.google.com/go/civil"},
+		{name: "TIMESTAMP", spannerType: "TIMESTAMP", wantType: "time.Time", wantImport: "time"},
+		{name: "ARRAY", spannerType: "ARRAY<INT64>", wantType: "[]spanner.NullInt64"},
+		{name: "ARRAY of BYTES", spannerType: "ARRAY<BYTES(MAX)>", wantType: "[][]byte"},
+		{name: "unknown", spannerType: "PROTO<Example>", wantType: genericType},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			gotType, gotImport := goType(tt.spannerType, tt.nullable)
+			if gotType != tt.wantType || gotImport != tt.wantImport {
+				t.Errorf("goType() = %v, %v, want %v, %v", gotType, gotImport, tt.wantType, tt.wantImport)
+			}
+		})
+	}
+}
+
+func Test_generate(t *testing.T) {
+	t.Parallel()
+
+	src, err := generate("fixtures", []dbinitiator.Table{
+		{
+			Name: "Users",
+			Columns: []dbinitiator.Column{
+				{Name: "Id", SpannerType: "STRING(MAX)"},
+				{Name: "CreatedAt", SpannerType: "TIMESTAMP"},
+				{Name: "Email", SpannerType: "STRING(MAX)", Nullable: true},
+				{Name: "EmailLower", SpannerType: "STRING(MAX)", Nullable: true, Generated: true},
+			},
+		},
+		{
+			Name: "Events",
+			Columns: []dbinitiator.Column{
+				{Name: "Id", SpannerType: "STRING(MAX)"},
+				{Name: "Mutation", SpannerType: "STRING(MAX)", Nullable: true},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("generate() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"package fixtures",
+		"\t\"time\"\n",
+		"type Users struct {",
+		"Email     spanner.NullString `spanner:\"Email\"`",
+		"func InsertUsers(ctx context.Context, client *spanner.Client, rows ...*Users) error {",
+		"MutationColumn spanner.NullString `spanner:\"Mutation\"`",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generate() missing %q in:\n%s", want, src)
+		}
+	}
+
+	if strings.Contains(string(src), "EmailLower") {
+		t.Errorf("generate() includes generated column EmailLower:\n%s", src)
+	}
+}
@@ -0,0 +1,139 @@
+// Command testdbgen generates Go fixture builders from the schema of a migrated database: a
+// struct per table with spanner tags, and helpers inserting rows. Run it from go:generate to
+// keep test fixtures in sync with the migrations:
+//
+//	//go:generate go run github.com/cccteam/db-initiator/cmd/testdbgen -migrations ../migrations -package fixtures -o fixtures_gen.go
+//
+// The schema is read from a throwaway database in an emulator container configured by the
+// TESTDB_* environment variables (see ConfigFromEnv).
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	dbinitiator "github.com/cccteam/db-initiator"
+	"github.com/go-playground/errors/v5"
+)
+
+// migrationsTable is the table golang-migrate records the schema version in
+const migrationsTable = "SchemaMigrations"
+
+func main() {
+	if err := run(context.Background(), os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "testdbgen: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string) error {
+	var migrations stringsFlag
+	fs := flag.NewFlagSet("testdbgen", flag.ContinueOnError)
+	fs.Var(&migrations, "migrations", "migrations directory, may be repeated")
+	pkg := fs.String("package", "fixtures", "package name of the generated code")
+	out := fs.String("o", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return errors.Wrap(err, "flag.FlagSet.Parse()")
+	}
+
+	if len(migrations) == 0 {
+		return errors.New("-migrations is required")
+	}
+
+	sources := make([]string, 0, len(migrations))
+	for _, dir := range migrations {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return errors.Wrap(err, "filepath.Abs()")
+		}
+		sources = append(sources, "file://"+abs)
+	}
+
+	tables, err := readTables(ctx, sources)
+	if err != nil {
+		return err
+	}
+
+	src, err := generate(*pkg, tables)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		if _, err := os.Stdout.Write(src); err != nil {
+			return errors.Wrap(err, "os.File.Write()")
+		}
+
+		return nil
+	}
+
+	if err := os.WriteFile(*out, src, 0o644); err != nil { //nolint:gosec // generated source is not sensitive
+		return errors.Wrap(err, "os.WriteFile()")
+	}
+
+	return nil
+}
+
+// readTables migrates a throwaway database up with sources and returns its tables
+func readTables(ctx context.Context, sources []string) ([]dbinitiator.Table, error) {
+	cfg, err := dbinitiator.ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	sp, err := dbinitiator.NewSpannerContainerFromConfig(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = sp.Close()
+		if !cfg.Reuse {
+			_ = sp.Terminate(ctx)
+		}
+	}()
+
+	db, err := sp.CreateTestDatabase(ctx, fmt.Sprintf("testdbgen-%d", time.Now().UnixNano()))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = db.DropDatabase(ctx)
+		_ = db.Close()
+	}()
+
+	if err := db.MigrateUp(sources...); err != nil {
+		return nil, err
+	}
+
+	tables, err := db.Tables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]dbinitiator.Table, 0, len(tables))
+	for _, t := range tables {
+		if t.Name != migrationsTable {
+			filtered = append(filtered, t)
+		}
+	}
+
+	return filtered, nil
+}
+
+// stringsFlag is a flag.Value collecting repeated string flags
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+
+	return nil
+}
@@ -0,0 +1,576 @@
+package dbinitiator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/go-playground/errors/v5"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// Environment variables read by ConfigFromEnv
+const (
+	// EnvImage sets the emulator image. A bare tag (e.g. "1.5.19") selects that version of
+	// the default emulator image, anything else is used as the full image reference.
+	EnvImage = "TESTDB_IMAGE"
+
+	// EnvProjectID sets the project the emulator instance is created in.
+	EnvProjectID = "TESTDB_PROJECT_ID"
+
+	// EnvInstanceID sets the emulator instance databases are created in.
+	EnvInstanceID = "TESTDB_INSTANCE_ID"
+
+	// EnvReuse reuses a running container with the same name instead of starting a new one.
+	EnvReuse = "TESTDB_REUSE"
+
+	// EnvContainerName sets the container name, which identifies the container to reuse.
+	EnvContainerName = "TESTDB_CONTAINER_NAME"
+
+	// EnvLabels adds labels to the container, as comma separated key=value pairs.
+	EnvLabels = "TESTDB_LABELS"
+
+	// EnvHostPort binds the emulator to a fixed port on the host.
+	EnvHostPort = "TESTDB_HOST_PORT"
+
+	// EnvDebug logs container diagnostics and every DDL statement executed to stderr.
+	EnvDebug = "TESTDB_DEBUG"
+
+	// EnvKeep keeps the databases of failed tests, and their container, for inspection.
+	EnvKeep = "TESTDB_KEEP"
+
+	// EnvStaleDatabaseTTL drops test databases older than the duration (e.g. "24h") at startup.
+	EnvStaleDatabaseTTL = "TESTDB_STALE_DATABASE_TTL"
+
+	// EnvMigrationsGlob enables migration discovery with the glob pattern (see
+	// WithMigrationDiscovery).
+	EnvMigrationsGlob = "TESTDB_MIGRATIONS_GLOB"
+)
+
+const (
+	defaultSpannerImage   = "gcr.io/cloud-spanner-emulator/emulator"
+	defaultContainerName  = "testdb-spanner"
+	defaultSpannerVersion = "latest"
+)
+
+var (
+	validProjectID  = regexp.MustCompile(`^[a-z][a-z0-9-]{4,28}[a-z0-9]$`)
+	validInstanceID = regexp.MustCompile(`^[a-z][a-z0-9-]{0,62}[a-z0-9]$`)
+)
+
+// Config holds the settings used to start a SpannerContainer
+type Config struct {
+	// Image is the full emulator image reference
+	Image string
+
+	// ProjectID is the project the emulator instance is created in
+	ProjectID string
+
+	// InstanceID is the emulator instance databases are created in
+	InstanceID string
+
+	// Reuse reuses a running container named ContainerName instead of starting a new one
+	Reuse bool
+
+	// ContainerName is the name given to the container
+	ContainerName string
+
+	// Labels are added to the container, e.g. to attribute it to a team, repo or CI job
+	Labels map[string]string
+
+	// HostPort binds the emulator to a fixed port on the host, from 1 to 65535, instead of a
+	// random one when zero
+	HostPort int
+
+	// Network is a docker network the container is attached to
+	Network string
+
+	// NetworkAliases are the names the container is reachable by on Network
+	NetworkAliases []string
+
+	// Keep skips teardown of databases belonging to failed tests, and of their container. The
+	// testcontainers reaper still removes the container when the test binary exits unless
+	// TESTCONTAINERS_RYUK_DISABLED=true is set.
+	Keep bool
+
+	// Toxiproxy routes all connections to the emulator through a toxiproxy sidecar
+	Toxiproxy bool
+
+	// Logger receives container lifecycle, database and migration events
+	Logger *slog.Logger
+
+	// Mounts are volume and bind mounts added to the container
+	Mounts testcontainers.ContainerMounts
+
+	// Tmpfs maps container paths to tmpfs mount options
+	Tmpfs map[string]string
+
+	// Files are copied into the container before it starts
+	Files []testcontainers.ContainerFile
+
+	// Migrations are the migration source URLs applied by SpannerDB.MigrateUp when it is called
+	// without any
+	Migrations []string
+
+	// MigrationsGlob, when Migrations is empty, locates the migrations applied by
+	// SpannerDB.MigrateUp in the working directory or its parents (see WithMigrationDiscovery)
+	MigrationsGlob string
+
+	// MaxConcurrentCreates bounds the number of databases created concurrently. Defaults to
+	// the number of CPUs.
+	MaxConcurrentCreates int
+
+	// MinSessions and MaxSessions size the session pool of the clients of test databases.
+	// Zero uses the spanner defaults.
+	MinSessions uint64
+	MaxSessions uint64
+
+	// Customizers modify the container request before the container is started
+	Customizers []testcontainers.ContainerCustomizer
+
+	// PostStartHooks run once the container has started and the emulator is ready
+	PostStartHooks []testcontainers.ContainerHook
+
+	// StaleDatabaseTTL drops test databases created more than the duration ago when the container
+	// starts, so a reused container does not fill up with leftovers of interrupted runs
+	StaleDatabaseTTL time.Duration
+
+	// Debug logs container diagnostics and every DDL statement executed. Without a Logger,
+	// records are written to stderr; otherwise the Logger must have debug level enabled.
+	Debug bool
+}
+
+// Option configures a SpannerContainer
+type Option func(*Config)
+
+// WithProjectID sets the project the emulator instance is created in
+func WithProjectID(projectID string) Option {
+	return func(c *Config) {
+		c.ProjectID = projectID
+	}
+}
+
+// WithInstanceID sets the emulator instance databases are created in
+func WithInstanceID(instanceID string) Option {
+	return func(c *Config) {
+		c.InstanceID = instanceID
+	}
+}
+
+// WithContainerName sets the container name shown by docker ps. Names must be unique on
+// the docker host unless the container is reused.
+func WithContainerName(name string) Option {
+	return func(c *Config) {
+		c.ContainerName = name
+	}
+}
+
+// WithLabels adds labels to the container so shared hosts can attribute and clean up
+// test containers.
+func WithLabels(labels map[string]string) Option {
+	return func(c *Config) {
+		c.Labels = mergeMaps(c.Labels, labels)
+	}
+}
+
+// WithHostPort binds the emulator to port on the host instead of a randomly assigned port,
+// for tooling that needs a stable address.
+func WithHostPort(port int) Option {
+	return func(c *Config) {
+		c.HostPort = port
+	}
+}
+
+// WithNetwork attaches the container to the docker network, reachable by the other
+// containers on it using aliases.
+func WithNetwork(network string, aliases ...string) Option {
+	return func(c *Config) {
+		c.Network = network
+		c.NetworkAliases = aliases
+	}
+}
+
+// WithLogger sets the logger used for container lifecycle, database creation and migration
+// events. By default nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Config) {
+		c.Logger = logger
+	}
+}
+
+// WithMounts adds volume and bind mounts to the container, e.g.
+// testcontainers.VolumeMount("name", "/data") or testcontainers.BindMount("/host", "/data").
+func WithMounts(mounts ...testcontainers.ContainerMount) Option {
+	return func(c *Config) {
+		c.Mounts = append(c.Mounts, mounts...)
+	}
+}
+
+// WithTmpfs mounts a tmpfs at each path in the container, with the mapped mount options
+// (e.g. "rw,size=64m").
+func WithTmpfs(tmpfs map[string]string) Option {
+	return func(c *Config) {
+		c.Tmpfs = mergeMaps(c.Tmpfs, tmpfs)
+	}
+}
+
+// WithFiles copies files, such as configuration or certificates, into the container before
+// it starts.
+func WithFiles(files ...testcontainers.ContainerFile) Option {
+	return func(c *Config) {
+		c.Files = append(c.Files, files...)
+	}
+}
+
+// WithMigrations sets the migration source URLs applied by SpannerDB.MigrateUp when it is
+// called without any, so tests do not repeat them.
+func WithMigrations(sourceURL ...string) Option {
+	return func(c *Config) {
+		c.Migrations = sourceURL
+	}
+}
+
+// WithMigrationDiscovery applies the first directory matching the glob pattern in the working
+// directory of the test, or its closest parent up to the module root, when SpannerDB.MigrateUp
+// is called without any sources, so tests do not depend on relative paths that differ between
+// packages. An empty pattern finds a directory named migrations. Migrations set with
+// WithMigrations take precedence.
+func WithMigrationDiscovery(pattern string) Option {
+	return func(c *Config) {
+		if pattern == "" {
+			pattern = DefaultMigrationsGlob
+		}
+		c.MigrationsGlob = pattern
+	}
+}
+
+// WithMaxConcurrentCreates bounds the number of databases created concurrently in the
+// container. Further calls to CreateTestDatabase wait for a slot.
+func WithMaxConcurrentCreates(n int) Option {
+	return func(c *Config) {
+		c.MaxConcurrentCreates = n
+	}
+}
+
+// WithSessionPool sizes the session pool of the clients of test databases. Zero keeps the
+// spanner default for that bound. A max below the default min also lowers the min.
+func WithSessionPool(minOpened, maxOpened uint64) Option {
+	return func(c *Config) {
+		c.MinSessions = minOpened
+		c.MaxSessions = maxOpened
+	}
+}
+
+// WithToxiproxy routes all connections to the emulator through a toxiproxy sidecar so
+// network faults can be injected. Faults apply to all databases in the container. See
+// SpannerContainer.Proxy.
+func WithToxiproxy() Option {
+	return func(c *Config) {
+		c.Toxiproxy = true
+	}
+}
+
+// WithKeepOnFailure skips teardown of databases belonging to failed tests, and of their
+// container, so they can be inspected. See SpannerDB.Cleanup. Set
+// TESTCONTAINERS_RYUK_DISABLED=true in the environment of the test binary, otherwise the
+// testcontainers reaper removes the container once the tests exit.
+func WithKeepOnFailure() Option {
+	return func(c *Config) {
+		c.Keep = true
+	}
+}
+
+// WithContainerCustomizers applies customizers, such as testcontainers.WithEnv, to the
+// container request, for settings without a dedicated option. Customizers run after the
+// request is built from the other options, so they can override them.
+func WithContainerCustomizers(customizers ...testcontainers.ContainerCustomizer) Option {
+	return func(c *Config) {
+		c.Customizers = append(c.Customizers, customizers...)
+	}
+}
+
+// WithPostStartHooks runs hooks once the container has started and the emulator is ready,
+// e.g. to run a script or copy files into the container. A hook returning an error fails
+// the container start.
+func WithPostStartHooks(hooks ...testcontainers.ContainerHook) Option {
+	return func(c *Config) {
+		c.PostStartHooks = append(c.PostStartHooks, hooks...)
+	}
+}
+
+// WithStaleDatabaseTTL drops test databases created more than ttl ago when the container
+// starts, cleaning up after interrupted runs against a reused container. See
+// SpannerContainer.DropStaleDatabases.
+func WithStaleDatabaseTTL(ttl time.Duration) Option {
+	return func(c *Config) {
+		c.StaleDatabaseTTL = ttl
+	}
+}
+
+// ConfigFromEnv returns a Config populated from the TESTDB_* environment variables, using
+// defaults for any that are unset.
+func ConfigFromEnv() (*Config, error) {
+	return configFromLookup(&Config{}, os.Getenv)
+}
+
+// configFromLookup returns a copy of base with the settings of the environment variables
+// found by getenv applied
+func configFromLookup(base *Config, getenv func(string) string) (*Config, error) {
+	cfg := *base
+	if v := getenv(EnvImage); v != "" {
+		cfg.Image = imageReference(v)
+	}
+	if v := getenv(EnvProjectID); v != "" {
+		cfg.ProjectID = v
+	}
+	if v := getenv(EnvInstanceID); v != "" {
+		cfg.InstanceID = v
+	}
+	if v := getenv(EnvContainerName); v != "" {
+		cfg.ContainerName = v
+	}
+
+	if v := getenv(EnvReuse); v != "" {
+		reuse, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value for %s: %q", EnvReuse, v)
+		}
+		cfg.Reuse = reuse
+	}
+
+	if v := getenv(EnvLabels); v != "" {
+		labels, err := parseLabels(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value for %s: %q", EnvLabels, v)
+		}
+		cfg.Labels = labels
+	}
+
+	if v := getenv(EnvDebug); v != "" {
+		debug, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value for %s: %q", EnvDebug, v)
+		}
+		cfg.Debug = debug
+	}
+
+	if v := getenv(EnvKeep); v != "" {
+		keep, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value for %s: %q", EnvKeep, v)
+		}
+		cfg.Keep = keep
+	}
+
+	if v := getenv(EnvHostPort); v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value for %s: %q", EnvHostPort, v)
+		}
+		cfg.HostPort = port
+	}
+
+	if v := getenv(EnvStaleDatabaseTTL); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value for %s: %q", EnvStaleDatabaseTTL, v)
+		}
+		cfg.StaleDatabaseTTL = ttl
+	}
+
+	if v := getenv(EnvMigrationsGlob); v != "" {
+		cfg.MigrationsGlob = v
+	}
+
+	return cfg.withDefaults(), nil
+}
+
+// parseLabels parses comma separated key=value pairs
+func parseLabels(s string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, errors.Newf("label %q is not a key=value pair", pair)
+		}
+		labels[key] = strings.TrimSpace(value)
+	}
+
+	return labels, nil
+}
+
+// mergeMaps returns a new map with the entries of a overridden by b
+func mergeMaps(a, b map[string]string) map[string]string {
+	merged := make(map[string]string, len(a)+len(b))
+	for k, v := range a {
+		merged[k] = v
+	}
+	for k, v := range b {
+		merged[k] = v
+	}
+
+	return merged
+}
+
+// withDefaults returns a copy of the Config with opts and then defaults applied
+func (c *Config) withDefaults(opts ...Option) *Config {
+	cfg := *c
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.Image == "" {
+		cfg.Image = imageReference(defaultSpannerVersion)
+	}
+	if cfg.ProjectID == "" {
+		cfg.ProjectID = defaultSpannerProjectID
+	}
+	if cfg.InstanceID == "" {
+		cfg.InstanceID = defaultSpannerInstanceID
+	}
+	if cfg.Reuse && cfg.ContainerName == "" {
+		cfg.ContainerName = defaultContainerName
+	}
+
+	return &cfg
+}
+
+// exposedPort returns the emulator port in the form expected by testcontainers, binding it
+// to HostPort when one is configured.
+func (c *Config) exposedPort() string {
+	if c.HostPort == 0 {
+		return defaultSpannerPort
+	}
+
+	return fmt.Sprintf("%d:%s", c.HostPort, defaultSpannerPort)
+}
+
+// validate checks the project and instance IDs follow the Spanner naming rules, so a bad
+// value fails at startup instead of on the first request.
+func (c *Config) validate() error {
+	if !validProjectID.MatchString(c.ProjectID) {
+		return errors.Newf("invalid project ID %q: must be 6 to 30 lowercase letters, digits or hyphens, starting with a letter", c.ProjectID)
+	}
+	if !validInstanceID.MatchString(c.InstanceID) {
+		return errors.Newf("invalid instance ID %q: must be 2 to 64 lowercase letters, digits or hyphens, starting with a letter", c.InstanceID)
+	}
+	if c.MaxSessions > 0 && c.MinSessions > c.MaxSessions {
+		return errors.Newf("invalid session pool: min sessions %d exceeds max sessions %d", c.MinSessions, c.MaxSessions)
+	}
+	// Zero leaves the port unset, so a random one is assigned
+	if c.HostPort < 0 || c.HostPort > 65535 {
+		return errors.Newf("invalid host port %d: must be between 1 and 65535", c.HostPort)
+	}
+
+	return nil
+}
+
+// maxConcurrentCreates returns the configured bound on concurrent database creation
+func (c *Config) maxConcurrentCreates() int {
+	if c.MaxConcurrentCreates > 0 {
+		return c.MaxConcurrentCreates
+	}
+
+	return runtime.NumCPU()
+}
+
+// sessionPoolConfig returns the session pool settings of test database clients
+func (c *Config) sessionPoolConfig() spanner.SessionPoolConfig {
+	pool := spanner.DefaultSessionPoolConfig
+	if c.MinSessions > 0 {
+		pool.MinOpened = c.MinSessions
+	}
+	if c.MaxSessions > 0 {
+		pool.MaxOpened = c.MaxSessions
+		// A max below the default min keeps the pool valid
+		pool.MinOpened = min(pool.MinOpened, pool.MaxOpened)
+	}
+
+	return pool
+}
+
+// logger returns the configured Logger. Without one, records are discarded, or written to
+// stderr when Debug is set.
+func (c *Config) logger() *slog.Logger {
+	switch {
+	case c.Logger != nil:
+		return c.Logger
+	case c.Debug:
+		return debugLogger()
+	default:
+		return discardLogger()
+	}
+}
+
+// containerRequest returns the testcontainers request to start the emulator container, with
+// the configured customizers applied
+func (c *Config) containerRequest(ctx context.Context) (testcontainers.GenericContainerRequest, error) {
+	var logger testcontainers.Logging
+	if c.Debug && c.Logger != nil && debugEnabled(ctx, c.Logger) {
+		logger = printfLogger{logger: c.Logger}
+	}
+
+	networks, aliases := c.networks()
+
+	var hooks []testcontainers.ContainerLifecycleHooks
+	if len(c.PostStartHooks) > 0 {
+		hooks = append(hooks, testcontainers.ContainerLifecycleHooks{PostReadies: c.PostStartHooks})
+	}
+
+	req := testcontainers.GenericContainerRequest{
+		Started: true,
+		Reuse:   c.Reuse,
+		Logger:  logger,
+		ContainerRequest: testcontainers.ContainerRequest{
+			Name:           c.ContainerName,
+			Image:          c.Image,
+			Labels:         c.Labels,
+			WaitingFor:     wait.ForLog("Cloud Spanner emulator running"),
+			ExposedPorts:   []string{c.exposedPort()},
+			Networks:       networks,
+			NetworkAliases: aliases,
+			Mounts:         c.Mounts,
+			Tmpfs:          c.Tmpfs,
+			Files:          c.Files,
+			LifecycleHooks: hooks,
+		},
+	}
+
+	for _, customizer := range c.Customizers {
+		if err := customizer.Customize(&req); err != nil {
+			return req, errors.Wrap(err, "testcontainers.ContainerCustomizer.Customize()")
+		}
+	}
+
+	return req, nil
+}
+
+// networks returns the networks and aliases in the form expected by testcontainers
+func (c *Config) networks() ([]string, map[string][]string) {
+	if c.Network == "" {
+		return nil, nil
+	}
+
+	return []string{c.Network}, map[string][]string{c.Network: c.NetworkAliases}
+}
+
+// imageReference returns the emulator image for image, which is either a tag of the
+// default emulator image or a full image reference.
+func imageReference(image string) string {
+	switch {
+	case image == "":
+		return ""
+	case strings.ContainsAny(image, ":/@"):
+		return image
+	default:
+		return defaultSpannerImage + ":" + image
+	}
+}
@@ -0,0 +1,149 @@
+package dbinitiator
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-playground/errors/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileName is the optional config file read by NewFromConfig. It is searched for in the
+// working directory and its parents, up to the module root.
+const ConfigFileName = "testdb.yaml"
+
+// fileConfig is the format of ConfigFileName. Migrations are directories relative to the
+// config file. Extensions are rejected, Spanner has none, and so are fixtures: seed data with
+// migrations or a Generator instead. Any other key is an error.
+type fileConfig struct {
+	Image                string            `yaml:"image"`
+	ProjectID            string            `yaml:"projectId"`
+	InstanceID           string            `yaml:"instanceId"`
+	Reuse                bool              `yaml:"reuse"`
+	ContainerName        string            `yaml:"containerName"`
+	Labels               map[string]string `yaml:"labels"`
+	HostPort             int               `yaml:"hostPort"`
+	Migrations           []string          `yaml:"migrations"`
+	MigrationsGlob       string            `yaml:"migrationsGlob"`
+	MaxConcurrentCreates int               `yaml:"maxConcurrentCreates"`
+	StaleDatabaseTTL     string            `yaml:"staleDatabaseTtl"`
+	Keep                 bool              `yaml:"keep"`
+	Debug                bool              `yaml:"debug"`
+	Extensions           []string          `yaml:"extensions"`
+	Fixtures             any               `yaml:"fixtures"`
+	Pool                 filePoolConfig    `yaml:"pool"`
+}
+
+// filePoolConfig sizes the session pool, see WithSessionPool
+type filePoolConfig struct {
+	MinSessions uint64 `yaml:"minSessions"`
+	MaxSessions uint64 `yaml:"maxSessions"`
+}
+
+// NewFromConfig returns an initialized SpannerContainer configured by ConfigFileName, if one
+// is found, then by the TESTDB_* environment variables and finally by opts, so services can
+// share a declarative test setup instead of repeating options in Go.
+func NewFromConfig(ctx context.Context, opts ...Option) (*SpannerContainer, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, errors.Wrap(err, "os.Getwd()")
+	}
+
+	base := &Config{}
+	if path, ok := findConfigFile(wd); ok {
+		if base, err = LoadConfigFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg, err := configFromLookup(base, os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSpannerContainerFromConfig(ctx, cfg, opts...)
+}
+
+// LoadConfigFile returns the Config described by the YAML file at path. See ConfigFileName.
+func LoadConfigFile(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "os.ReadFile()")
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+
+	var fc fileConfig
+	// An empty file has no document, which is an empty config
+	if err := dec.Decode(&fc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, errors.Wrapf(err, "invalid config file %s", path)
+	}
+
+	if len(fc.Extensions) > 0 {
+		return nil, errors.Newf("invalid config file %s: extensions %v are not supported, Spanner has no extensions", path, fc.Extensions)
+	}
+	if fc.Fixtures != nil {
+		return nil, errors.Newf("invalid config file %s: fixtures are not supported, seed data with migrations or SpannerDB.NewGenerator()", path)
+	}
+
+	cfg := &Config{
+		Image:                imageReference(fc.Image),
+		ProjectID:            fc.ProjectID,
+		InstanceID:           fc.InstanceID,
+		Reuse:                fc.Reuse,
+		ContainerName:        fc.ContainerName,
+		Labels:               fc.Labels,
+		HostPort:             fc.HostPort,
+		MigrationsGlob:       fc.MigrationsGlob,
+		MaxConcurrentCreates: fc.MaxConcurrentCreates,
+		MinSessions:          fc.Pool.MinSessions,
+		MaxSessions:          fc.Pool.MaxSessions,
+		Keep:                 fc.Keep,
+		Debug:                fc.Debug,
+	}
+
+	if fc.StaleDatabaseTTL != "" {
+		if cfg.StaleDatabaseTTL, err = time.ParseDuration(fc.StaleDatabaseTTL); err != nil {
+			return nil, errors.Wrapf(err, "invalid staleDatabaseTtl in config file %s", path)
+		}
+	}
+
+	for _, dir := range fc.Migrations {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(filepath.Dir(path), dir)
+		}
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, errors.Wrap(err, "filepath.Abs()")
+		}
+		cfg.Migrations = append(cfg.Migrations, "file://"+filepath.ToSlash(abs))
+	}
+
+	return cfg, nil
+}
+
+// findConfigFile returns the path of ConfigFileName in dir or its closest ancestor. The
+// search stops at the module root, the first directory containing go.mod.
+func findConfigFile(dir string) (string, bool) {
+	for {
+		path := filepath.Join(dir, ConfigFileName)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return "", false
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,141 @@
+package dbinitiator
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		want    *Config
+		wantErr string
+	}{
+		{
+			name: "full",
+			content: `
+image: 1.5.19
+projectId: project
+instanceId: instance
+reuse: true
+labels:
+  team: data
+migrations:
+  - migrations
+maxConcurrentCreates: 4
+staleDatabaseTtl: 24h
+pool:
+  minSessions: 10
+  maxSessions: 50
+`,
+			want: &Config{
+				Image:                "gcr.io/cloud-spanner-emulator/emulator:1.5.19",
+				ProjectID:            "project",
+				InstanceID:           "instance",
+				Reuse:                true,
+				Labels:               map[string]string{"team": "data"},
+				Migrations:           []string{"file://" + filepath.ToSlash(filepath.Join(dir, "full", "migrations"))},
+				MaxConcurrentCreates: 4,
+				MinSessions:          10,
+				MaxSessions:          50,
+				StaleDatabaseTTL:     24 * time.Hour,
+			},
+		},
+		{
+			name:    "empty",
+			content: "",
+			want:    &Config{},
+		},
+		{
+			name:    "unknown field",
+			content: "poolSize: 10\n",
+			wantErr: "field poolSize not found",
+		},
+		{
+			name:    "extensions",
+			content: "extensions: [btree_gist]\n",
+			wantErr: "Spanner has no extensions",
+		},
+		{
+			name:    "fixtures",
+			content: "fixtures: [testdata/users.sql]\n",
+			wantErr: "fixtures are not supported",
+		},
+		{
+			name:    "invalid ttl",
+			content: "staleDatabaseTtl: 1 day\n",
+			wantErr: "invalid staleDatabaseTtl",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(dir, tt.name, ConfigFileName)
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatalf("os.MkdirAll() error = %v", err)
+			}
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("os.WriteFile() error = %v", err)
+			}
+
+			got, err := LoadConfigFile(path)
+			if (err != nil) != (tt.wantErr != "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("LoadConfigFile() error = %v, wantErr %q", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadConfigFile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_findConfigFile(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, dir := range []string{"module/pkg/store", "other/pkg"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatalf("os.MkdirAll() error = %v", err)
+		}
+	}
+	for _, file := range []string{ConfigFileName, "module/go.mod", "module/pkg/" + ConfigFileName} {
+		if err := os.WriteFile(filepath.Join(root, file), nil, 0o600); err != nil {
+			t.Fatalf("os.WriteFile() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		dir    string
+		want   string
+		wantOK bool
+	}{
+		{name: "in ancestor", dir: "module/pkg/store", want: "module/pkg/" + ConfigFileName, wantOK: true},
+		{name: "stops at module root", dir: "module"},
+		{name: "without module root", dir: "other/pkg", want: ConfigFileName, wantOK: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := findConfigFile(filepath.Join(root, tt.dir))
+			if ok != tt.wantOK {
+				t.Fatalf("findConfigFile() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if tt.wantOK && got != filepath.Join(root, tt.want) {
+				t.Errorf("findConfigFile() = %v, want %v", got, filepath.Join(root, tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,358 @@
+package dbinitiator
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func Test_configFromLookup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    *Config
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			env:  map[string]string{},
+			want: &Config{
+				Image:      "gcr.io/cloud-spanner-emulator/emulator:latest",
+				ProjectID:  "unit-testing",
+				InstanceID: "test-instance",
+			},
+		},
+		{
+			name: "image tag and reuse",
+			env: map[string]string{
+				EnvImage: "1.5.19",
+				EnvReuse: "true",
+			},
+			want: &Config{
+				Image:         "gcr.io/cloud-spanner-emulator/emulator:1.5.19",
+				ProjectID:     "unit-testing",
+				InstanceID:    "test-instance",
+				Reuse:         true,
+				ContainerName: "testdb-spanner",
+			},
+		},
+		{
+			name: "full image reference and names",
+			env: map[string]string{
+				EnvImage:         "mirror.example.com/emulator:1.5.19",
+				EnvProjectID:     "project",
+				EnvInstanceID:    "instance",
+				EnvContainerName: "spanner",
+			},
+			want: &Config{
+				Image:         "mirror.example.com/emulator:1.5.19",
+				ProjectID:     "project",
+				InstanceID:    "instance",
+				ContainerName: "spanner",
+			},
+		},
+		{
+			name: "host port",
+			env:  map[string]string{EnvHostPort: "55432"},
+			want: &Config{
+				Image:      "gcr.io/cloud-spanner-emulator/emulator:latest",
+				ProjectID:  "unit-testing",
+				InstanceID: "test-instance",
+				HostPort:   55432,
+			},
+		},
+		{
+			name: "keep",
+			env:  map[string]string{EnvKeep: "1"},
+			want: &Config{
+				Image:      "gcr.io/cloud-spanner-emulator/emulator:latest",
+				ProjectID:  "unit-testing",
+				InstanceID: "test-instance",
+				Keep:       true,
+			},
+		},
+		{
+			name: "debug",
+			env:  map[string]string{EnvDebug: "1"},
+			want: &Config{
+				Image:      "gcr.io/cloud-spanner-emulator/emulator:latest",
+				ProjectID:  "unit-testing",
+				InstanceID: "test-instance",
+				Debug:      true,
+			},
+		},
+		{
+			name: "labels",
+			env:  map[string]string{EnvLabels: "team=data, ci_job=1234"},
+			want: &Config{
+				Image:      "gcr.io/cloud-spanner-emulator/emulator:latest",
+				ProjectID:  "unit-testing",
+				InstanceID: "test-instance",
+				Labels:     map[string]string{"team": "data", "ci_job": "1234"},
+			},
+		},
+		{
+			name: "stale database ttl",
+			env:  map[string]string{EnvStaleDatabaseTTL: "24h"},
+			want: &Config{
+				Image:            "gcr.io/cloud-spanner-emulator/emulator:latest",
+				ProjectID:        "unit-testing",
+				InstanceID:       "test-instance",
+				StaleDatabaseTTL: 24 * time.Hour,
+			},
+		},
+		{
+			name: "migrations glob",
+			env:  map[string]string{EnvMigrationsGlob: "db/migrations"},
+			want: &Config{
+				Image:          "gcr.io/cloud-spanner-emulator/emulator:latest",
+				ProjectID:      "unit-testing",
+				InstanceID:     "test-instance",
+				MigrationsGlob: "db/migrations",
+			},
+		},
+		{
+			name:    "invalid stale database ttl",
+			env:     map[string]string{EnvStaleDatabaseTTL: "1 day"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid labels",
+			env:     map[string]string{EnvLabels: "team"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid host port",
+			env:     map[string]string{EnvHostPort: "port"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid reuse",
+			env:     map[string]string{EnvReuse: "sometimes"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := configFromLookup(&Config{}, func(key string) string { return tt.env[key] })
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("configFromLookup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("configFromLookup() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_exposedPort(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{
+			name: "random host port",
+			want: "9010/tcp",
+		},
+		{
+			name: "fixed host port",
+			opts: []Option{WithHostPort(55432)},
+			want: "55432:9010/tcp",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := (&Config{}).withDefaults(tt.opts...)
+			if got := cfg.exposedPort(); got != tt.want {
+				t.Errorf("Config.exposedPort() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_sessionPoolConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantMin uint64
+		wantMax uint64
+	}{
+		{
+			name:    "defaults",
+			wantMin: spanner.DefaultSessionPoolConfig.MinOpened,
+			wantMax: spanner.DefaultSessionPoolConfig.MaxOpened,
+		},
+		{
+			name:    "min and max",
+			opts:    []Option{WithSessionPool(10, 50)},
+			wantMin: 10,
+			wantMax: 50,
+		},
+		{
+			name:    "max below default min",
+			opts:    []Option{WithSessionPool(0, 5)},
+			wantMin: 5,
+			wantMax: 5,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pool := (&Config{}).withDefaults(tt.opts...).sessionPoolConfig()
+			if pool.MinOpened != tt.wantMin || pool.MaxOpened != tt.wantMax {
+				t.Errorf("Config.sessionPoolConfig() = (%d, %d), want (%d, %d)", pool.MinOpened, pool.MaxOpened, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestConfig_validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name: "custom ids",
+			opts: []Option{WithProjectID("my-project-1"), WithInstanceID("i1")},
+		},
+		{
+			name:    "project id too short",
+			opts:    []Option{WithProjectID("proj")},
+			wantErr: true,
+		},
+		{
+			name:    "project id with uppercase",
+			opts:    []Option{WithProjectID("My-Project")},
+			wantErr: true,
+		},
+		{
+			name:    "instance id ending in hyphen",
+			opts:    []Option{WithInstanceID("instance-")},
+			wantErr: true,
+		},
+		{
+			name:    "instance id starting with digit",
+			opts:    []Option{WithInstanceID("1instance")},
+			wantErr: true,
+		},
+		{
+			name: "session pool max only",
+			opts: []Option{WithSessionPool(0, 10)},
+		},
+		{
+			name:    "session pool min exceeds max",
+			opts:    []Option{WithSessionPool(20, 10)},
+			wantErr: true,
+		},
+		{
+			name: "host port",
+			opts: []Option{WithHostPort(9010)},
+		},
+		{
+			name: "highest host port",
+			opts: []Option{WithHostPort(65535)},
+		},
+		{
+			name:    "negative host port",
+			opts:    []Option{WithHostPort(-1)},
+			wantErr: true,
+		},
+		{
+			name:    "host port out of range",
+			opts:    []Option{WithHostPort(65536)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if err := (&Config{}).withDefaults(tt.opts...).validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Config.validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_containerRequest(t *testing.T) {
+	t.Parallel()
+
+	hook := func(context.Context, testcontainers.Container) error { return nil }
+	failing := testcontainers.CustomizeRequestOption(func(*testcontainers.GenericContainerRequest) error {
+		return errors.New("customizer failed")
+	})
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		wantEnv   map[string]string
+		wantHooks int
+		wantErr   bool
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name:    "customizers",
+			opts:    []Option{WithContainerCustomizers(testcontainers.WithEnv(map[string]string{"KEY": "value"}))},
+			wantEnv: map[string]string{"KEY": "value"},
+		},
+		{
+			name:      "post start hooks",
+			opts:      []Option{WithPostStartHooks(hook, hook)},
+			wantHooks: 2,
+		},
+		{
+			name:    "customizer error",
+			opts:    []Option{WithContainerCustomizers(failing)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			req, err := (&Config{}).withDefaults(tt.opts...).containerRequest(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Config.containerRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(tt.wantEnv) > 0 && !reflect.DeepEqual(req.Env, tt.wantEnv) {
+				t.Errorf("Config.containerRequest() Env = %v, want %v", req.Env, tt.wantEnv)
+			}
+
+			var hooks int
+			for _, h := range req.LifecycleHooks {
+				hooks += len(h.PostReadies)
+			}
+			if hooks != tt.wantHooks {
+				t.Errorf("Config.containerRequest() post start hooks = %d, want %d", hooks, tt.wantHooks)
+			}
+		})
+	}
+}
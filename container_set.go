@@ -0,0 +1,131 @@
+package dbinitiator
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/go-playground/errors/v5"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/network"
+)
+
+// ContainerFunc starts a container attached to the docker network named network
+type ContainerFunc func(ctx context.Context, network string) (testcontainers.Container, error)
+
+// ContainerSet starts a group of containers concurrently on a shared docker network and
+// terminates them together. Containers capture the typed value they start, for example:
+//
+//	var sp *SpannerContainer
+//	set := NewContainerSet(func(ctx context.Context, network string) (testcontainers.Container, error) {
+//		var err error
+//		sp, err = NewSpannerContainer(ctx, "latest", WithNetwork(network, "spanner"))
+//
+//		return sp, err
+//	})
+type ContainerSet struct {
+	funcs []ContainerFunc
+
+	mu         sync.Mutex
+	network    *testcontainers.DockerNetwork
+	containers []testcontainers.Container
+}
+
+// NewContainerSet returns a ContainerSet that starts a container with each of funcs
+func NewContainerSet(funcs ...ContainerFunc) *ContainerSet {
+	return &ContainerSet{funcs: funcs}
+}
+
+// Network returns the name of the shared docker network, or an empty string if the set
+// has not been started.
+func (s *ContainerSet) Network() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.network == nil {
+		return ""
+	}
+
+	return s.network.Name
+}
+
+// Start creates the shared network and starts all containers concurrently. If any container
+// fails to start, the containers that did start are terminated. Starting a set again before
+// Terminate returns ErrContainerSetStarted.
+func (s *ContainerSet) Start(ctx context.Context) error {
+	s.mu.Lock()
+	if s.network != nil {
+		s.mu.Unlock()
+
+		return ErrContainerSetStarted
+	}
+	nw, err := network.New(ctx)
+	if err != nil {
+		s.mu.Unlock()
+
+		return errors.Wrap(err, "network.New()")
+	}
+	s.network = nw
+	s.mu.Unlock()
+
+	containers := make([]testcontainers.Container, len(s.funcs))
+	errs := make([]error, len(s.funcs))
+
+	var wg sync.WaitGroup
+	for i, fn := range s.funcs {
+		wg.Add(1)
+		go func(i int, fn ContainerFunc) {
+			defer wg.Done()
+			containers[i], errs[i] = fn(ctx, nw.Name)
+		}(i, fn)
+	}
+	wg.Wait()
+
+	s.mu.Lock()
+	for i := range containers {
+		if errs[i] == nil && containers[i] != nil {
+			s.containers = append(s.containers, containers[i])
+		}
+	}
+	s.mu.Unlock()
+
+	for _, err := range errs {
+		if err != nil {
+			_ = s.Terminate(ctx)
+
+			return errors.Wrap(err, "failed to start container set")
+		}
+	}
+
+	return nil
+}
+
+// Terminate closes and terminates all started containers and removes the shared network.
+// The first error encountered is returned after all resources have been released.
+func (s *ContainerSet) Terminate(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var firstErr error
+	for i := len(s.containers) - 1; i >= 0; i-- {
+		c := s.containers[i]
+		if closer, ok := c.(io.Closer); ok {
+			if err := closer.Close(); err != nil && firstErr == nil {
+				firstErr = errors.Wrap(err, "io.Closer.Close()")
+			}
+		}
+		if err := c.Terminate(ctx); err != nil && firstErr == nil {
+			firstErr = errors.Wrap(err, "testcontainers.Container.Terminate()")
+		}
+	}
+	s.containers = nil
+
+	if s.network != nil {
+		if err := s.network.Remove(ctx); err != nil && firstErr == nil {
+			firstErr = errors.Wrap(err, "testcontainers.DockerNetwork.Remove()")
+		}
+		s.network = nil
+	}
+
+	return firstErr
+}
@@ -0,0 +1,112 @@
+package dbinitiator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestContainerSet(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var sp1, sp2 *SpannerContainer
+	set := NewContainerSet(
+		func(ctx context.Context, network string) (testcontainers.Container, error) {
+			var err error
+			sp1, err = NewSpannerContainer(ctx, "latest", WithNetwork(network, "spanner1"))
+
+			return sp1, err
+		},
+		func(ctx context.Context, network string) (testcontainers.Container, error) {
+			var err error
+			sp2, err = NewSpannerContainer(ctx, "latest", WithNetwork(network, "spanner2"))
+
+			return sp2, err
+		},
+	)
+	t.Cleanup(func() { _ = set.Terminate(ctx) })
+	if err := set.Start(ctx); err != nil {
+		t.Fatalf("ContainerSet.Start() error = %v", err)
+	}
+
+	network := set.Network()
+	if network == "" {
+		t.Errorf("ContainerSet.Network() is empty")
+	}
+
+	if err := set.Start(ctx); !errors.Is(err, ErrContainerSetStarted) {
+		t.Errorf("ContainerSet.Start() error = %v, want %v when already started", err, ErrContainerSetStarted)
+	}
+	if set.Network() != network {
+		t.Errorf("ContainerSet.Network() = %v, want %v after second Start()", set.Network(), network)
+	}
+
+	for _, sp := range []*SpannerContainer{sp1, sp2} {
+		state, err := sp.State(ctx)
+		if err != nil {
+			t.Fatalf("container.State() error = %v", err)
+		}
+		if !state.Running {
+			t.Errorf("container.State() = %v, want %v", state.Status, "running")
+		}
+	}
+
+	if err := set.Terminate(ctx); err != nil {
+		t.Fatalf("ContainerSet.Terminate() error = %v", err)
+	}
+
+	if set.Network() != "" {
+		t.Errorf("ContainerSet.Network() = %v, want empty after Terminate()", set.Network())
+	}
+}
+
+func TestContainerSet_Start_rollback(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	errStart := errors.New("container failed to start")
+
+	var sp *SpannerContainer
+	var networkName string
+	set := NewContainerSet(
+		func(ctx context.Context, network string) (testcontainers.Container, error) {
+			var err error
+			sp, err = NewSpannerContainer(ctx, "latest", WithNetwork(network, "spanner"))
+
+			return sp, err
+		},
+		func(_ context.Context, network string) (testcontainers.Container, error) {
+			networkName = network
+
+			return nil, errStart
+		},
+	)
+	t.Cleanup(func() { _ = set.Terminate(ctx) })
+
+	if err := set.Start(ctx); !errors.Is(err, errStart) {
+		t.Fatalf("ContainerSet.Start() error = %v, want %v", err, errStart)
+	}
+
+	if sp == nil {
+		t.Fatalf("NewSpannerContainer() did not start the first container")
+	}
+	if _, err := sp.State(ctx); err == nil {
+		t.Errorf("container.State() error = nil, want error for the terminated container")
+	}
+
+	if set.Network() != "" {
+		t.Errorf("ContainerSet.Network() = %v, want empty after a failed Start()", set.Network())
+	}
+	provider, err := testcontainers.NewDockerProvider()
+	if err != nil {
+		t.Fatalf("testcontainers.NewDockerProvider() error = %v", err)
+	}
+	defer provider.Close()
+	if _, err := provider.GetNetwork(ctx, testcontainers.NetworkRequest{Name: networkName}); err == nil {
+		t.Errorf("testcontainers.DockerProvider.GetNetwork(%s) error = nil, want the network removed", networkName)
+	}
+}
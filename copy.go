@@ -0,0 +1,176 @@
+package dbinitiator
+
+import (
+	"context"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	"github.com/go-playground/errors/v5"
+	"google.golang.org/api/iterator"
+)
+
+// copyBatchMutations bounds the mutations, one per column of each row, inserted per commit by
+// CopyDatabase, keeping commits well below the Spanner mutation limit, which also counts
+// secondary index entries
+const copyBatchMutations = 10_000
+
+// CopyDatabase creates a database named dstName in dst with the schema and rows of src, which
+// may be in another container. Use it for matrix tests that prepare data once and verify
+// behavior on other emulator versions. Rows are streamed from a single consistent snapshot of
+// src and inserted parents first, in commits of at most copyBatchMutations column values, so
+// tables are never held in memory.
+func CopyDatabase(ctx context.Context, src *SpannerDB, dst *SpannerContainer, dstName string) (*SpannerDB, error) {
+	ddl, err := src.admin.GetDatabaseDdl(ctx, &databasepb.GetDatabaseDdlRequest{Database: src.dbStr})
+	if err != nil {
+		return nil, errors.Wrap(err, "database.DatabaseAdminClient.GetDatabaseDdl()")
+	}
+
+	tables, err := src.copyOrder(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := dst.CreateTestDatabase(ctx, dstName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.copyFrom(ctx, src, ddl.GetStatements(), tables); err != nil {
+		_ = db.DropDatabase(ctx)
+		_ = db.Close()
+
+		return nil, errors.Wrapf(err, "failed to copy database %s", src.dbStr)
+	}
+
+	return db, nil
+}
+
+// copyFrom applies the ddl statements and copies the rows of tables from src
+func (db *SpannerDB) copyFrom(ctx context.Context, src *SpannerDB, ddl, tables []string) error {
+	if len(ddl) > 0 {
+		if db.debug {
+			db.logger.Debug("executing DDL", "database", db.dbStr, "statements", ddl)
+		}
+		op, err := db.admin.UpdateDatabaseDdl(ctx, &databasepb.UpdateDatabaseDdlRequest{Database: db.dbStr, Statements: ddl})
+		if err != nil {
+			return errors.Wrap(err, "database.DatabaseAdminClient.UpdateDatabaseDdl()")
+		}
+		if err := op.Wait(ctx); err != nil {
+			return errors.Wrap(err, "database.UpdateDatabaseDdlOperation.Wait()")
+		}
+	}
+
+	txn := src.ReadOnlyTransaction()
+	defer txn.Close()
+
+	for _, table := range tables {
+		if err := db.copyTable(ctx, src, txn, table); err != nil {
+			return err
+		}
+	}
+	db.logger.Info("copied database", "source", src.dbStr, "database", db.dbStr, "tables", len(tables))
+
+	return nil
+}
+
+// copyBatchRows returns the number of rows of a table with the number of columns inserted per
+// commit by CopyDatabase
+func copyBatchRows(columns int) int {
+	if columns == 0 {
+		return 1
+	}
+
+	return max(1, copyBatchMutations/columns)
+}
+
+// copyTable streams the rows of table read by txn from src, inserting them in batches
+func (db *SpannerDB) copyTable(ctx context.Context, src *SpannerDB, txn *spanner.ReadOnlyTransaction, table string) error {
+	columns, err := src.writableColumns(ctx, table)
+	if err != nil {
+		return err
+	}
+
+	batchRows := copyBatchRows(len(columns))
+	batch := make([]*spanner.Mutation, 0, batchRows)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if _, err := db.Apply(ctx, batch); err != nil {
+			return errors.Wrapf(err, "spanner.Client.Apply(): table %s", table)
+		}
+		batch = batch[:0]
+
+		return nil
+	}
+
+	iter := txn.Read(ctx, table, spanner.AllKeys(), columns)
+	defer iter.Stop()
+	for {
+		r, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to read table %s", table)
+		}
+
+		values := make([]any, r.Size())
+		for i := range values {
+			var col spanner.GenericColumnValue
+			if err := r.Column(i, &col); err != nil {
+				return errors.Wrapf(err, "spanner.Row.Column(): column %s", columns[i])
+			}
+			values[i] = col
+		}
+		batch = append(batch, spanner.Insert(table, columns, values))
+
+		if len(batch) == batchRows {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return flush()
+}
+
+// copyOrder returns the tables of the database ordered so that interleaved parents and
+// tables referenced by foreign keys come before the tables referencing them
+func (db *SpannerDB) copyOrder(ctx context.Context) ([]string, error) {
+	tables, err := db.Tables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deps := make(map[string][]string, len(tables))
+	for _, t := range tables {
+		refs, err := db.references(ctx, t.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range refs {
+			deps[t.Name] = append(deps[t.Name], ref.table)
+		}
+	}
+
+	order := make([]string, 0, len(tables))
+	visited := make(map[string]bool, len(tables))
+	var visit func(table string)
+	visit = func(table string) {
+		// Marking the table before its dependencies stops at self references and cycles
+		if visited[table] {
+			return
+		}
+		visited[table] = true
+		for _, dep := range deps[table] {
+			visit(dep)
+		}
+		order = append(order, table)
+	}
+	for _, t := range tables {
+		visit(t.Name)
+	}
+
+	return order, nil
+}
@@ -0,0 +1,91 @@
+package dbinitiator
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestCopyDatabase(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	src, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = src.Terminate(ctx) })
+
+	dst, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = dst.Terminate(ctx) })
+
+	srcDB, err := src.CreateTestDatabase(ctx, "copy-source")
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateTestDatabase() error = %v", err)
+	}
+	srcDB.Cleanup(t)
+
+	if err := srcDB.MigrateUp("file://testdata/generate"); err != nil {
+		t.Fatalf("SpannerDB.MigrateUp() error = %v", err)
+	}
+
+	tables := []string{"Customers", "Addresses", "Orders"}
+	g := srcDB.NewGenerator(1)
+	for _, table := range tables {
+		if err := g.Fill(ctx, table, 10); err != nil {
+			t.Fatalf("Generator.Fill(%s) error = %v", table, err)
+		}
+	}
+
+	dstDB, err := CopyDatabase(ctx, srcDB, dst, "copy-target")
+	if err != nil {
+		t.Fatalf("CopyDatabase() error = %v", err)
+	}
+	dstDB.Cleanup(t)
+
+	dump := func(db *SpannerDB, table string) string {
+		w := &bytes.Buffer{}
+		if err := db.DumpTableJSON(ctx, table, w); err != nil {
+			t.Fatalf("SpannerDB.DumpTableJSON() error = %v", err)
+		}
+
+		return w.String()
+	}
+	for _, table := range tables {
+		if got, want := dump(dstDB, table), dump(srcDB, table); got != want {
+			t.Errorf("CopyDatabase() table %s = %v, want %v", table, got, want)
+		}
+	}
+}
+
+func Test_copyBatchRows(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		columns int
+		want    int
+	}{
+		{name: "one column", columns: 1, want: copyBatchMutations},
+		{name: "wide table", columns: 40, want: copyBatchMutations / 40},
+		{name: "wider than the batch", columns: copyBatchMutations + 1, want: 1},
+		{name: "no columns", columns: 0, want: 1},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := copyBatchRows(tt.columns)
+			if got != tt.want {
+				t.Errorf("copyBatchRows() = %v, want %v", got, tt.want)
+			}
+			if got*tt.columns > copyBatchMutations && got > 1 {
+				t.Errorf("copyBatchRows() = %v rows of %d columns, exceeding %d mutations", got, tt.columns, copyBatchMutations)
+			}
+		})
+	}
+}
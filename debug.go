@@ -0,0 +1,80 @@
+package dbinitiator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// debugLogger returns a logger writing debug records to stderr, used for TESTDB_DEBUG
+// when no logger is configured
+func debugLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+// debugEnabled reports whether logger records debug level diagnostics
+func debugEnabled(ctx context.Context, logger *slog.Logger) bool {
+	return logger.Enabled(ctx, slog.LevelDebug)
+}
+
+// printfLogger adapts a slog.Logger to the printf style loggers used by testcontainers
+// and migrate, logging at debug level.
+type printfLogger struct {
+	logger *slog.Logger
+}
+
+func (l printfLogger) Printf(format string, v ...any) {
+	l.logger.Debug(fmt.Sprintf(format, v...))
+}
+
+// Verbose enables verbose logging from migrate
+func (l printfLogger) Verbose() bool {
+	return true
+}
+
+// logContainerDiagnostics logs the container inspect output, mapped ports and image digest
+// of container at debug level. Failures to collect diagnostics are logged, not returned.
+func logContainerDiagnostics(ctx context.Context, logger *slog.Logger, container testcontainers.Container) {
+	if !debugEnabled(ctx, logger) {
+		return
+	}
+
+	if ports, err := container.Ports(ctx); err != nil {
+		logger.Debug("failed to get mapped ports", "error", err)
+	} else {
+		logger.Debug("container mapped ports", "container_id", container.GetContainerID(), "ports", ports)
+	}
+
+	cli, err := testcontainers.NewDockerClientWithOpts(ctx)
+	if err != nil {
+		logger.Debug("failed to create docker client", "error", err)
+
+		return
+	}
+	defer cli.Close()
+
+	inspect, err := cli.ContainerInspect(ctx, container.GetContainerID())
+	if err != nil {
+		logger.Debug("failed to inspect container", "error", err)
+
+		return
+	}
+
+	if b, err := json.Marshal(inspect); err != nil {
+		logger.Debug("failed to marshal container inspect output", "error", err)
+	} else {
+		logger.Debug("container inspect", "container_id", container.GetContainerID(), "inspect", string(b))
+	}
+
+	image, _, err := cli.ImageInspectWithRaw(ctx, inspect.Image)
+	if err != nil {
+		logger.Debug("failed to inspect image", "error", err)
+
+		return
+	}
+	logger.Debug("container image", "image_id", image.ID, "repo_digests", image.RepoDigests, "repo_tags", image.RepoTags)
+}
@@ -0,0 +1,78 @@
+package dbinitiator
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/go-playground/errors/v5"
+)
+
+// DefaultMigrationsGlob is the pattern WithMigrationDiscovery uses without one
+const DefaultMigrationsGlob = "migrations"
+
+// defaultMigrations returns the migration source URLs applied by SpannerDB.MigrateUp when it
+// is called without any
+func (sp *SpannerContainer) defaultMigrations() ([]string, error) {
+	if len(sp.migrations) > 0 || sp.migrationsGlob == "" {
+		return sp.migrations, nil
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, errors.Wrap(err, "os.Getwd()")
+	}
+
+	source, err := discoverMigrations(wd, sp.migrationsGlob)
+	if err != nil {
+		return nil, errors.Wrap(err, "discoverMigrations()")
+	}
+	sp.logger.Info("discovered migrations", "source", source)
+
+	return []string{source}, nil
+}
+
+// discoverMigrations returns the file source URL of the first directory matching pattern in
+// dir or its closest parent, up to the module root
+func discoverMigrations(dir, pattern string) (string, error) {
+	path, ok, err := findUp(dir, pattern)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", errors.Newf("no migrations directory matching %q found in %s or its parents", pattern, dir)
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", errors.Wrap(err, "filepath.Abs()")
+	}
+
+	return "file://" + filepath.ToSlash(abs), nil
+}
+
+// findUp returns the first directory matching the glob pattern in dir or its closest
+// ancestor, skipping matches that are files. The search stops at the module root, the first
+// directory containing go.mod. A malformed pattern returns filepath.ErrBadPattern.
+func findUp(dir, pattern string) (string, bool, error) {
+	for {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return "", false, errors.Wrapf(err, "filepath.Glob(): pattern %q", pattern)
+		}
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && info.IsDir() {
+				return match, true, nil
+			}
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return "", false, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false, nil
+		}
+		dir = parent
+	}
+}
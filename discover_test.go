@@ -0,0 +1,99 @@
+package dbinitiator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_discoverMigrations(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, dir := range []string{"module/migrations", "module/db/spanner-migrations", "module/pkg/store"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatalf("os.MkdirAll() error = %v", err)
+		}
+	}
+	for _, file := range []string{"module/go.mod", "module/pkg/schema"} {
+		if err := os.WriteFile(filepath.Join(root, file), nil, 0o600); err != nil {
+			t.Fatalf("os.WriteFile() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", pattern: DefaultMigrationsGlob, want: "module/migrations"},
+		{name: "glob", pattern: "db/*-migrations", want: "module/db/spanner-migrations"},
+		{name: "file is skipped", pattern: "schema", wantErr: true},
+		{name: "bad pattern", pattern: "[", wantErr: true},
+		{name: "not found", pattern: "fixtures", wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := discoverMigrations(filepath.Join(root, "module/pkg/store"), tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("discoverMigrations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if want := "file://" + filepath.ToSlash(filepath.Join(root, tt.want)); !tt.wantErr && got != want {
+				t.Errorf("discoverMigrations() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func Test_findUp(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, dir := range []string{"outside", "module/migrations", "module/pkg/store"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatalf("os.MkdirAll() error = %v", err)
+		}
+	}
+	for _, file := range []string{"outside.txt", "module/go.mod", "module/pkg/migrations"} {
+		if err := os.WriteFile(filepath.Join(root, file), nil, 0o600); err != nil {
+			t.Fatalf("os.WriteFile() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		dir     string
+		pattern string
+		want    string
+		wantOK  bool
+		wantErr error
+	}{
+		{name: "in ancestor", dir: "module/pkg/store", pattern: "migrations", want: "module/migrations", wantOK: true},
+		{name: "glob", dir: "module/pkg/store", pattern: "migr*", want: "module/migrations", wantOK: true},
+		{name: "stops at module root", dir: "module/pkg/store", pattern: "outside*"},
+		{name: "not found", dir: "module/pkg", pattern: "fixtures"},
+		{name: "bad pattern", dir: "module/pkg", pattern: "[", wantErr: filepath.ErrBadPattern},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok, err := findUp(filepath.Join(root, tt.dir), tt.pattern)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("findUp() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if ok != tt.wantOK {
+				t.Fatalf("findUp() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if tt.wantOK && got != filepath.Join(root, tt.want) {
+				t.Errorf("findUp() = %v, want %v", got, filepath.Join(root, tt.want))
+			}
+		})
+	}
+}
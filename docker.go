@@ -0,0 +1,66 @@
+package dbinitiator
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// dockerProbeTimeout bounds how long RequireDocker and SkipIfNoDocker wait for the daemon
+const dockerProbeTimeout = 10 * time.Second
+
+// dockerGuidance explains how to make a Docker daemon reachable to testcontainers
+const dockerGuidance = `Docker is required to run the Spanner emulator. Check that a daemon is running and reachable:
+  - Docker Desktop or dockerd: the socket is /var/run/docker.sock, or set DOCKER_HOST
+  - colima: export DOCKER_HOST=unix://$HOME/.colima/default/docker.sock
+  - podman: export DOCKER_HOST=unix://$(podman machine inspect --format '{{.ConnectionInfo.PodmanSocket.Path}}')
+    and TESTCONTAINERS_RYUK_DISABLED=true`
+
+// RequireDocker fails the test immediately, with guidance on configuring the Docker host, when
+// the Docker daemon is not reachable. Call it first in tests to avoid the deep testcontainers
+// error returned when starting a container. In TestMain, use CheckDocker.
+func RequireDocker(tb TB) {
+	tb.Helper()
+
+	if err := probeDocker(); err != nil {
+		tb.Fatalf("%s", dockerUnavailableError(err))
+	}
+}
+
+// CheckDocker returns an error, with guidance on configuring the Docker host, when the Docker
+// daemon is not reachable. The error matches ErrDockerUnavailable, unless ctx is done first,
+// then ctx.Err() is returned without guidance. It is RequireDocker for TestMain, where there
+// is no test to fail.
+func CheckDocker(ctx context.Context) error {
+	if err := checkDocker(ctx); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr //nolint:wrapcheck // the caller's context error is returned as is
+		}
+
+		return dockerUnavailableError(err)
+	}
+
+	return nil
+}
+
+// SkipIfNoDocker skips the test when the Docker daemon is not reachable, for suites that run
+// in environments without Docker.
+func SkipIfNoDocker(tb TB) {
+	tb.Helper()
+
+	if err := probeDocker(); err != nil {
+		tb.Skipf("testdb: skipping, Docker is unavailable: %s", err)
+	}
+}
+
+// dockerUnavailableError adds guidance on configuring the Docker host to err
+func dockerUnavailableError(err error) error {
+	return fmt.Errorf("testdb: %w\n%s", err, dockerGuidance)
+}
+
+func probeDocker() error {
+	ctx, cancel := context.WithTimeout(context.Background(), dockerProbeTimeout)
+	defer cancel()
+
+	return checkDocker(ctx)
+}
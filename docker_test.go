@@ -0,0 +1,66 @@
+package dbinitiator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequireDocker(t *testing.T) {
+	t.Parallel()
+
+	RequireDocker(t)
+	SkipIfNoDocker(t)
+	if err := CheckDocker(context.Background()); err != nil {
+		t.Errorf("CheckDocker() error = %v", err)
+	}
+}
+
+func TestCheckDocker_canceled(t *testing.T) {
+	t.Parallel()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want error
+	}{
+		{name: "canceled", ctx: canceled, want: context.Canceled},
+		{name: "deadline exceeded", ctx: expired, want: context.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := CheckDocker(tt.ctx)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("CheckDocker() error = %v, want %v", err, tt.want)
+			}
+			if errors.Is(err, ErrDockerUnavailable) || strings.Contains(err.Error(), dockerGuidance) {
+				t.Errorf("CheckDocker() error = %q, want the context error without guidance", err)
+			}
+		})
+	}
+}
+
+func Test_dockerUnavailableError(t *testing.T) {
+	t.Parallel()
+
+	cause := withSentinel(ErrDockerUnavailable, errors.New("client.Client.Ping(): Cannot connect to the Docker daemon"))
+	err := dockerUnavailableError(cause)
+	if !errors.Is(err, ErrDockerUnavailable) {
+		t.Errorf("errors.Is(dockerUnavailableError(), ErrDockerUnavailable) = false, want true")
+	}
+
+	want := "testdb: docker is unavailable: client.Client.Ping(): Cannot connect to the Docker daemon\n" + dockerGuidance
+	if err.Error() != want {
+		t.Errorf("dockerUnavailableError() = %q, want %q", err, want)
+	}
+}
@@ -0,0 +1,76 @@
+package dbinitiator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-playground/errors/v5"
+	"github.com/testcontainers/testcontainers-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Error is the type of the sentinel errors returned by this package. Errors are wrapped
+// with additional context, so use errors.Is or errors.As to test for them.
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+const (
+	// ErrDatabaseAlreadyExists is returned when creating a database that already exists.
+	ErrDatabaseAlreadyExists Error = "database already exists"
+
+	// ErrContainerStartFailed is returned when the database container could not be started.
+	ErrContainerStartFailed Error = "container failed to start"
+
+	// ErrMigrationFailed is returned when applying migrations fails.
+	ErrMigrationFailed Error = "migration failed"
+
+	// ErrDockerUnavailable is returned when the Docker daemon cannot be reached.
+	ErrDockerUnavailable Error = "docker is unavailable"
+
+	// ErrContainerSetStarted is returned when starting a ContainerSet that is already started.
+	ErrContainerSetStarted Error = "container set already started"
+)
+
+// withSentinel returns an error that matches both sentinel and err when tested with errors.Is
+func withSentinel(sentinel, err error) error {
+	return fmt.Errorf("%w: %w", sentinel, err)
+}
+
+// createDatabaseError classifies an error returned from creating a database
+func createDatabaseError(err error) error {
+	if status.Code(err) == codes.AlreadyExists {
+		return withSentinel(ErrDatabaseAlreadyExists, err)
+	}
+
+	return err
+}
+
+// checkDocker verifies the Docker daemon is reachable. testcontainers panics when it cannot
+// find a Docker host, so the panic is recovered and reported as ErrDockerUnavailable.
+func checkDocker(ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = withSentinel(ErrDockerUnavailable, errors.Newf("%v", r))
+		}
+	}()
+
+	cli, err := testcontainers.NewDockerClientWithOpts(ctx)
+	if err != nil {
+		return withSentinel(ErrDockerUnavailable, errors.Wrap(err, "testcontainers.NewDockerClientWithOpts()"))
+	}
+	defer cli.Close()
+
+	if _, err := cli.Ping(ctx); err != nil {
+		if ctx.Err() != nil {
+			return errors.Wrap(err, "client.Client.Ping()")
+		}
+
+		return withSentinel(ErrDockerUnavailable, errors.Wrap(err, "client.Client.Ping()"))
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package dbinitiator
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSentinelErrors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	db, err := container.CreateTestDatabase(ctx, "sentinel")
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateTestDatabase() error = %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	if _, err := container.CreateTestDatabase(ctx, "sentinel"); !errors.Is(err, ErrDatabaseAlreadyExists) {
+		t.Errorf("SpannerContainer.CreateTestDatabase() error = %v, want %v", err, ErrDatabaseAlreadyExists)
+	}
+
+	if err := db.MigrateUp("file://testdata/migration_error"); !errors.Is(err, ErrMigrationFailed) {
+		t.Errorf("SpannerDB.MigrateUp() error = %v, want %v", err, ErrMigrationFailed)
+	}
+}
+
+func Test_withSentinel(t *testing.T) {
+	t.Parallel()
+
+	cause := errors.New("cause")
+	err := withSentinel(ErrContainerStartFailed, cause)
+
+	if !errors.Is(err, ErrContainerStartFailed) {
+		t.Errorf("errors.Is(err, ErrContainerStartFailed) = false, want true")
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("errors.Is(err, cause) = false, want true")
+	}
+
+	var target Error
+	if !errors.As(err, &target) || target != ErrContainerStartFailed {
+		t.Errorf("errors.As() = %v, want %v", target, ErrContainerStartFailed)
+	}
+}
@@ -0,0 +1,410 @@
+package dbinitiator
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/go-playground/errors/v5"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	// maxGenerateAttempts is the number of times a row is regenerated when it violates a
+	// unique index, foreign key or CHECK constraint
+	maxGenerateAttempts = 10
+
+	// maxGeneratedLength caps the length of generated STRING and BYTES values
+	maxGeneratedLength = 16
+)
+
+// Generator fills tables with random rows that satisfy the constraints of a migrated schema.
+// Given the same seed, schema and database contents, the same rows are generated. A
+// Generator is not safe for concurrent use.
+type Generator struct {
+	db    *SpannerDB
+	rng   *rand.Rand
+	apply func(ctx context.Context, ms []*spanner.Mutation, opts ...spanner.ApplyOption) (time.Time, error)
+}
+
+// generatorColumn is a writable column of a table being filled
+type generatorColumn struct {
+	name        string
+	spannerType string
+	nullable    bool
+}
+
+// reference is a foreign key or interleaving, mapping columns of a table to the key
+// columns of the table they reference
+type reference struct {
+	table      string
+	columns    []string
+	refColumns []string
+}
+
+// NewGenerator returns a Generator filling tables of db using a random source seeded with seed.
+func (db *SpannerDB) NewGenerator(seed int64) *Generator {
+	return db.NewGeneratorWithSource(rand.NewSource(seed))
+}
+
+// NewGeneratorWithSource returns a Generator filling tables of db using src for randomness.
+func (db *SpannerDB) NewGeneratorWithSource(src rand.Source) *Generator {
+	return &Generator{
+		db:    db,
+		rng:   rand.New(src), //nolint:gosec // deterministic test data, not security sensitive
+		apply: db.Apply,
+	}
+}
+
+// Fill inserts n random rows into table. NOT NULL columns are always set and nullable
+// columns are occasionally NULL. Foreign key and interleaved parent key columns take their
+// values from existing rows of the referenced table, so referenced tables must be filled
+// first. Rows violating a unique index or CHECK constraint are regenerated.
+func (g *Generator) Fill(ctx context.Context, table string, n int) error {
+	columns, err := g.db.generatorColumns(ctx, table)
+	if err != nil {
+		return err
+	}
+
+	refs, err := g.db.references(ctx, table)
+	if err != nil {
+		return err
+	}
+
+	refRows := make([][][]any, len(refs))
+	for i, ref := range refs {
+		if refRows[i], err = g.db.readValues(ctx, ref.table, ref.refColumns); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		if err := g.insertRow(ctx, table, columns, refs, refRows); err != nil {
+			return errors.Wrapf(err, "failed to insert row %d into table %s", i, table)
+		}
+	}
+
+	return nil
+}
+
+// insertRow inserts a random row, regenerating it when it violates a constraint
+func (g *Generator) insertRow(ctx context.Context, table string, columns []generatorColumn, refs []reference, refRows [][][]any) error {
+	var err error
+	for attempt := 0; attempt < maxGenerateAttempts; attempt++ {
+		var m *spanner.Mutation
+		if m, err = g.row(table, columns, refs, refRows); err != nil {
+			return err
+		}
+
+		if _, err = g.apply(ctx, []*spanner.Mutation{m}); err == nil {
+			return nil
+		}
+
+		switch spanner.ErrCode(err) {
+		case codes.AlreadyExists, codes.FailedPrecondition, codes.OutOfRange:
+		default:
+			return errors.Wrap(err, "spanner.Client.Apply()")
+		}
+	}
+
+	return errors.Wrapf(err, "no valid row generated after %d attempts", maxGenerateAttempts)
+}
+
+// row returns an insert mutation for a random row of table
+func (g *Generator) row(table string, columns []generatorColumn, refs []reference, refRows [][][]any) (*spanner.Mutation, error) {
+	nullable := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		nullable[c.name] = c.nullable
+	}
+
+	values := make(map[string]any, len(columns))
+	for i, ref := range refs {
+		if len(refRows[i]) == 0 {
+			for _, c := range ref.columns {
+				if !nullable[c] {
+					return nil, errors.Newf("table %s references table %s, which has no rows", table, ref.table)
+				}
+				values[c] = nil
+			}
+
+			continue
+		}
+
+		row := refRows[i][g.rng.Intn(len(refRows[i]))]
+		for j, c := range ref.columns {
+			// Columns shared by several references (e.g. an interleaved parent key that is
+			// also a foreign key) keep the first value chosen
+			if _, ok := values[c]; !ok {
+				values[c] = row[j]
+			}
+		}
+	}
+
+	names := make([]string, 0, len(columns))
+	vals := make([]any, 0, len(columns))
+	for _, c := range columns {
+		v, ok := values[c.name]
+		if !ok {
+			var err error
+			if v, err = g.value(c); err != nil {
+				return nil, errors.Wrapf(err, "column %s", c.name)
+			}
+		}
+		names = append(names, c.name)
+		vals = append(vals, v)
+	}
+
+	return spanner.Insert(table, names, vals), nil
+}
+
+// value returns a random value for column, or nil for one in ten values of nullable columns
+func (g *Generator) value(column generatorColumn) (any, error) {
+	if column.nullable && g.rng.Intn(10) == 0 {
+		return nil, nil
+	}
+
+	return randomValue(g.rng, column.spannerType)
+}
+
+// randomValue returns a random value of spannerType, as reported by INFORMATION_SCHEMA.COLUMNS
+func randomValue(rng *rand.Rand, spannerType string) (any, error) {
+	if elemType, ok := strings.CutPrefix(spannerType, "ARRAY<"); ok {
+		return randomArray(rng, strings.TrimSuffix(elemType, ">"))
+	}
+
+	baseType, size := splitSpannerType(spannerType)
+	switch baseType {
+	case "BOOL":
+		return rng.Intn(2) == 1, nil
+	case "INT64":
+		return rng.Int63(), nil
+	case "FLOAT64":
+		return rng.NormFloat64() * 1000, nil
+	case "FLOAT32":
+		return float32(rng.NormFloat64() * 1000), nil
+	case "NUMERIC":
+		return fmt.Sprintf("%d.%09d", rng.Int63n(1_000_000_000), rng.Int63n(1_000_000_000)), nil
+	case "STRING":
+		return randomString(rng, size), nil
+	case "BYTES":
+		return []byte(randomString(rng, size)), nil
+	case "DATE":
+		return randomTime(rng).Format(time.DateOnly), nil
+	case "TIMESTAMP":
+		return randomTime(rng), nil
+	case "JSON":
+		return spanner.NullJSON{Value: map[string]any{"value": randomString(rng, maxGeneratedLength)}, Valid: true}, nil
+	default:
+		return nil, errors.Newf("unsupported column type %s", spannerType)
+	}
+}
+
+// randomArray returns a random array of up to three elements of elemType
+func randomArray(rng *rand.Rand, elemType string) (any, error) {
+	n := rng.Intn(4)
+	baseType, _ := splitSpannerType(elemType)
+	switch baseType {
+	case "BOOL":
+		return randomSlice[bool](rng, n, elemType)
+	case "INT64":
+		return randomSlice[int64](rng, n, elemType)
+	case "FLOAT64":
+		return randomSlice[float64](rng, n, elemType)
+	case "FLOAT32":
+		return randomSlice[float32](rng, n, elemType)
+	case "NUMERIC", "STRING", "DATE":
+		return randomSlice[string](rng, n, elemType)
+	case "BYTES":
+		return randomSlice[[]byte](rng, n, elemType)
+	case "TIMESTAMP":
+		return randomSlice[time.Time](rng, n, elemType)
+	case "JSON":
+		return randomSlice[spanner.NullJSON](rng, n, elemType)
+	default:
+		return nil, errors.Newf("unsupported column type ARRAY<%s>", elemType)
+	}
+}
+
+func randomSlice[T any](rng *rand.Rand, n int, elemType string) ([]T, error) {
+	s := make([]T, n)
+	for i := range s {
+		v, err := randomValue(rng, elemType)
+		if err != nil {
+			return nil, err
+		}
+
+		var ok bool
+		if s[i], ok = v.(T); !ok {
+			return nil, errors.Newf("unexpected value %T for type %s", v, elemType)
+		}
+	}
+
+	return s, nil
+}
+
+// splitSpannerType splits a type such as STRING(36) into its base type and size. The size
+// is maxGeneratedLength for MAX, unsized types and sizes above maxGeneratedLength.
+func splitSpannerType(spannerType string) (baseType string, size int) {
+	baseType, rest, ok := strings.Cut(spannerType, "(")
+	if !ok {
+		return spannerType, maxGeneratedLength
+	}
+
+	size, err := strconv.Atoi(strings.TrimSuffix(rest, ")"))
+	if err != nil || size > maxGeneratedLength {
+		return baseType, maxGeneratedLength
+	}
+
+	return baseType, size
+}
+
+func randomString(rng *rand.Rand, maxLen int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	b := make([]byte, 1+rng.Intn(maxLen))
+	for i := range b {
+		b[i] = letters[rng.Intn(len(letters))]
+	}
+
+	return string(b)
+}
+
+// randomTime returns a random time, truncated to microseconds, between 2000 and 2050
+func randomTime(rng *rand.Rand) time.Time {
+	start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	d := time.Duration(rng.Int63n(int64(50 * 365 * 24 * time.Hour)))
+
+	return start.Add(d).Truncate(time.Microsecond)
+}
+
+// generatorColumns returns the writable columns of table in the order they were defined
+func (db *SpannerDB) generatorColumns(ctx context.Context, table string) ([]generatorColumn, error) {
+	stmt := spanner.Statement{
+		SQL: `SELECT COLUMN_NAME, SPANNER_TYPE, IS_NULLABLE FROM INFORMATION_SCHEMA.COLUMNS
+			WHERE TABLE_SCHEMA = '' AND TABLE_NAME = @table AND IS_GENERATED = 'NEVER'
+			ORDER BY ORDINAL_POSITION`,
+		Params: map[string]any{"table": table},
+	}
+
+	var columns []generatorColumn
+	if err := db.Single().Query(ctx, stmt).Do(func(r *spanner.Row) error {
+		var name, spannerType, nullable string
+		if err := r.Columns(&name, &spannerType, &nullable); err != nil {
+			return errors.Wrap(err, "spanner.Row.Columns()")
+		}
+		columns = append(columns, generatorColumn{name: name, spannerType: spannerType, nullable: nullable == "YES"})
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to list columns for table %s", table)
+	}
+
+	if len(columns) == 0 {
+		return nil, errors.Newf("table %s not found", table)
+	}
+
+	return columns, nil
+}
+
+// references returns the interleaved parent and foreign keys of table
+func (db *SpannerDB) references(ctx context.Context, table string) ([]reference, error) {
+	var refs []reference
+
+	var parent spanner.NullString
+	if err := db.Single().Query(ctx, spanner.Statement{
+		SQL:    `SELECT PARENT_TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = '' AND TABLE_NAME = @table`,
+		Params: map[string]any{"table": table},
+	}).Do(func(r *spanner.Row) error {
+		if err := r.Column(0, &parent); err != nil {
+			return errors.Wrap(err, "spanner.Row.Column()")
+		}
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to get parent of table %s", table)
+	}
+
+	if parent.Valid {
+		keyColumns, err := db.queryColumns(ctx, parent.StringVal, spanner.Statement{
+			SQL: `SELECT COLUMN_NAME FROM INFORMATION_SCHEMA.INDEX_COLUMNS
+				WHERE TABLE_SCHEMA = '' AND TABLE_NAME = @table AND INDEX_TYPE = 'PRIMARY_KEY'
+				ORDER BY ORDINAL_POSITION`,
+			Params: map[string]any{"table": parent.StringVal},
+		})
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, reference{table: parent.StringVal, columns: keyColumns, refColumns: keyColumns})
+	}
+
+	foreignKeys, err := db.foreignKeys(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(refs, foreignKeys...), nil
+}
+
+// foreignKeys returns the foreign keys of table
+func (db *SpannerDB) foreignKeys(ctx context.Context, table string) ([]reference, error) {
+	stmt := spanner.Statement{
+		SQL: `SELECT rc.CONSTRAINT_NAME, kcu.COLUMN_NAME, ref.TABLE_NAME, ref.COLUMN_NAME
+			FROM INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS AS rc
+			JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE AS kcu
+				ON kcu.CONSTRAINT_SCHEMA = rc.CONSTRAINT_SCHEMA AND kcu.CONSTRAINT_NAME = rc.CONSTRAINT_NAME
+			JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE AS ref
+				ON ref.CONSTRAINT_SCHEMA = rc.UNIQUE_CONSTRAINT_SCHEMA AND ref.CONSTRAINT_NAME = rc.UNIQUE_CONSTRAINT_NAME
+				AND ref.ORDINAL_POSITION = kcu.POSITION_IN_UNIQUE_CONSTRAINT
+			WHERE kcu.TABLE_SCHEMA = '' AND kcu.TABLE_NAME = @table
+			ORDER BY rc.CONSTRAINT_NAME, kcu.ORDINAL_POSITION`,
+		Params: map[string]any{"table": table},
+	}
+
+	var refs []reference
+	var current string
+	if err := db.Single().Query(ctx, stmt).Do(func(r *spanner.Row) error {
+		var constraint, column, refTable, refColumn string
+		if err := r.Columns(&constraint, &column, &refTable, &refColumn); err != nil {
+			return errors.Wrap(err, "spanner.Row.Columns()")
+		}
+		if constraint != current || len(refs) == 0 {
+			refs = append(refs, reference{table: refTable})
+			current = constraint
+		}
+		ref := &refs[len(refs)-1]
+		ref.columns = append(ref.columns, column)
+		ref.refColumns = append(ref.refColumns, refColumn)
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to list foreign keys for table %s", table)
+	}
+
+	return refs, nil
+}
+
+// readValues returns the values of columns for all rows of table, ordered by primary key
+func (db *SpannerDB) readValues(ctx context.Context, table string, columns []string) ([][]any, error) {
+	var rows [][]any
+	if err := db.Single().Read(ctx, table, spanner.AllKeys(), columns).Do(func(r *spanner.Row) error {
+		values := make([]any, r.Size())
+		for i := range values {
+			var col spanner.GenericColumnValue
+			if err := r.Column(i, &col); err != nil {
+				return errors.Wrapf(err, "spanner.Row.Column(): column %s", columns[i])
+			}
+			values[i] = col
+		}
+		rows = append(rows, values)
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to read table %s", table)
+	}
+
+	return rows, nil
+}
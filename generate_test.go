@@ -0,0 +1,196 @@
+package dbinitiator
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_splitSpannerType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		spannerType string
+		wantType    string
+		wantSize    int
+	}{
+		{name: "unsized", spannerType: "INT64", wantType: "INT64", wantSize: maxGeneratedLength},
+		{name: "max", spannerType: "STRING(MAX)", wantType: "STRING", wantSize: maxGeneratedLength},
+		{name: "small", spannerType: "STRING(4)", wantType: "STRING", wantSize: 4},
+		{name: "large", spannerType: "BYTES(1024)", wantType: "BYTES", wantSize: maxGeneratedLength},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			gotType, gotSize := splitSpannerType(tt.spannerType)
+			if gotType != tt.wantType || gotSize != tt.wantSize {
+				t.Errorf("splitSpannerType() = %v, %v, want %v, %v", gotType, gotSize, tt.wantType, tt.wantSize)
+			}
+		})
+	}
+}
+
+func Test_randomValue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		spannerType string
+		wantType    reflect.Type
+		wantErr     bool
+	}{
+		{name: "BOOL", spannerType: "BOOL", wantType: reflect.TypeOf(false)},
+		{name: "INT64", spannerType: "INT64", wantType: reflect.TypeOf(int64(0))},
+		{name: "FLOAT32", spannerType: "FLOAT32", wantType: reflect.TypeOf(float32(0))},
+		{name: "STRING", spannerType: "STRING(8)", wantType: reflect.TypeOf("")},
+		{name: "BYTES", spannerType: "BYTES(MAX)", wantType: reflect.TypeOf([]byte{})},
+		{name: "TIMESTAMP", spannerType: "TIMESTAMP", wantType: reflect.TypeOf(time.Time{})},
+		{name: "JSON", spannerType: "JSON", wantType: reflect.TypeOf(spanner.NullJSON{})},
+		{name: "ARRAY", spannerType: "ARRAY<STRING(MAX)>", wantType: reflect.TypeOf([]string{})},
+		{name: "unsupported", spannerType: "PROTO<Example>", wantErr: true},
+		{name: "unsupported ARRAY", spannerType: "ARRAY<PROTO<Example>>", wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := randomValue(rand.New(rand.NewSource(1)), tt.spannerType)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("randomValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if reflect.TypeOf(got) != tt.wantType {
+				t.Errorf("randomValue() = %T, want %v", got, tt.wantType)
+			}
+
+			again, _ := randomValue(rand.New(rand.NewSource(1)), tt.spannerType)
+			if !reflect.DeepEqual(got, again) {
+				t.Errorf("randomValue() = %v, then %v with the same seed", got, again)
+			}
+		})
+	}
+}
+
+func TestGenerator_insertRow(t *testing.T) {
+	t.Parallel()
+
+	checkViolation := status.Error(codes.OutOfRange, "Check constraint `Orders`.`Orders_Quantity` is violated")
+	giveUp := make([]error, maxGenerateAttempts)
+	for i := range giveUp {
+		giveUp[i] = checkViolation
+	}
+	columns := []generatorColumn{{name: "OrderId", spannerType: "INT64"}, {name: "Quantity", spannerType: "INT64"}}
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "first row valid", errs: []error{nil}, wantCalls: 1},
+		{name: "retries check violations", errs: []error{checkViolation, checkViolation, nil}, wantCalls: 3},
+		{name: "retries unique violations", errs: []error{status.Error(codes.AlreadyExists, "row exists"), nil}, wantCalls: 2},
+		{name: "gives up", errs: giveUp, wantCalls: maxGenerateAttempts, wantErr: true},
+		{name: "other errors not retried", errs: []error{status.Error(codes.PermissionDenied, "denied")}, wantCalls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			run := func() ([]*spanner.Mutation, error) {
+				var applied []*spanner.Mutation
+				g := &Generator{
+					rng: rand.New(rand.NewSource(1)),
+					apply: func(_ context.Context, ms []*spanner.Mutation, _ ...spanner.ApplyOption) (time.Time, error) {
+						applied = append(applied, ms...)
+
+						return time.Time{}, tt.errs[len(applied)-1]
+					},
+				}
+				err := g.insertRow(context.Background(), "Orders", columns, nil, nil)
+
+				return applied, err
+			}
+
+			applied, err := run()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Generator.insertRow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(applied) != tt.wantCalls {
+				t.Fatalf("Generator.insertRow() applied %d rows, want %d", len(applied), tt.wantCalls)
+			}
+			for i := 1; i < len(applied); i++ {
+				if reflect.DeepEqual(applied[i], applied[i-1]) {
+					t.Errorf("Generator.insertRow() retried with the same row %d", i)
+				}
+			}
+
+			again, _ := run()
+			if !reflect.DeepEqual(applied, again) {
+				t.Errorf("Generator.insertRow() generated different rows for the same source")
+			}
+		})
+	}
+}
+
+func TestGenerator_Fill(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	fill := func(name string) map[string]string {
+		db, err := container.CreateTestDatabase(ctx, name)
+		if err != nil {
+			t.Fatalf("SpannerContainer.CreateTestDatabase() error = %v", err)
+		}
+		db.Cleanup(t)
+
+		if err := db.MigrateUp("file://testdata/generate"); err != nil {
+			t.Fatalf("SpannerDB.MigrateUp() error = %v", err)
+		}
+
+		g := db.NewGenerator(42)
+		if err := g.Fill(ctx, "Orders", 1); err == nil {
+			t.Errorf("Generator.Fill() error = nil, want error when referenced table is empty")
+		}
+
+		dumps := make(map[string]string)
+		for _, table := range []string{"Customers", "Addresses", "Orders"} {
+			if err := g.Fill(ctx, table, 20); err != nil {
+				t.Fatalf("Generator.Fill(%s) error = %v", table, err)
+			}
+
+			w := &bytes.Buffer{}
+			if err := db.DumpTableJSON(ctx, table, w); err != nil {
+				t.Fatalf("SpannerDB.DumpTableJSON() error = %v", err)
+			}
+			dumps[table] = w.String()
+		}
+
+		return dumps
+	}
+
+	first := fill("generate-first")
+	second := fill("generate-second")
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Generator.Fill() generated different rows for the same seed")
+	}
+}
@@ -0,0 +1,88 @@
+package dbinitiator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-playground/errors/v5"
+)
+
+// keptRecordFile is the file in os.TempDir() recording kept databases for later cleanup
+const keptRecordFile = "testdb-kept.txt"
+
+// Cleanup registers a cleanup function with t that drops and closes the database when the
+// test completes. If the container was started with WithKeepOnFailure (or TESTDB_KEEP) and
+// the test failed, the database and its container are kept instead, the commands to inspect
+// them are logged, and the database is recorded in testdb-kept.txt in os.TempDir().
+func (db *SpannerDB) Cleanup(t TB) {
+	t.Helper()
+
+	t.Cleanup(func() {
+		if t.Failed() && db.container != nil && db.container.keep {
+			db.container.kept.Store(true)
+			t.Logf("testdb: keeping database %s\n"+
+				"  SPANNER_EMULATOR_HOST=%s gcloud spanner databases execute-sql %s --project=%s --instance=%s --sql='SELECT 1'",
+				db.dbStr, db.container.endpoint, db.name(), db.container.projectID, db.container.instanceID)
+
+			if err := db.recordKept(); err != nil {
+				t.Errorf("SpannerDB.recordKept() err=%s", err)
+			}
+			if err := db.Close(); err != nil {
+				t.Errorf("SpannerDB.Close() err=%s", err)
+			}
+
+			return
+		}
+
+		if err := db.DropDatabase(context.Background()); err != nil {
+			t.Errorf("SpannerDB.DropDatabase() err=%s", err)
+		}
+		if err := db.Close(); err != nil {
+			t.Errorf("SpannerDB.Close() err=%s", err)
+		}
+	})
+}
+
+// name returns the database name without the project and instance path
+func (db *SpannerDB) name() string {
+	return path.Base(db.dbStr)
+}
+
+// recordKept appends the kept database to the record file
+func (db *SpannerDB) recordKept() error {
+	f, err := os.OpenFile(filepath.Join(os.TempDir(), keptRecordFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "os.OpenFile()")
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintf(f, "%s\t%s\t%s\n", db.container.GetContainerID(), db.container.endpoint, db.dbStr); err != nil {
+		return errors.Wrap(err, "fmt.Fprintf()")
+	}
+
+	return nil
+}
+
+// keptDatabases returns the names of the databases recorded in the record file
+func keptDatabases() (map[string]bool, error) {
+	b, err := os.ReadFile(filepath.Join(os.TempDir(), keptRecordFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "os.ReadFile()")
+	}
+
+	kept := make(map[string]bool)
+	for _, line := range strings.Split(string(b), "\n") {
+		if fields := strings.Split(line, "\t"); len(fields) == 3 {
+			kept[fields[2]] = true
+		}
+	}
+
+	return kept, nil
+}
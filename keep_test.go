@@ -0,0 +1,77 @@
+package dbinitiator
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSpannerDB_Cleanup(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest", WithKeepOnFailure())
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	//nolint:paralleltest // the subtest must complete before the database is recreated
+	t.Run("passing test drops database", func(t *testing.T) {
+		db, err := container.CreateTestDatabase(ctx, "cleanup")
+		if err != nil {
+			t.Fatalf("SpannerContainer.CreateTestDatabase() error = %v", err)
+		}
+		db.Cleanup(t)
+	})
+
+	db, err := container.CreateTestDatabase(ctx, "cleanup")
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateTestDatabase() error = %v, database was not dropped", err)
+	}
+	db.Cleanup(t)
+
+	if container.kept.Load() {
+		t.Errorf("SpannerContainer.kept = true, want false")
+	}
+}
+
+//nolint:paralleltest // sets TMPDIR, which holds the record of kept databases
+func TestSpannerDB_Cleanup_failedTest(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest", WithKeepOnFailure())
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() {
+		container.kept.Store(false)
+		_ = container.Terminate(ctx)
+	})
+
+	db, err := container.CreateTestDatabase(ctx, "kept")
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateTestDatabase() error = %v", err)
+	}
+
+	tb := &recordingTB{TB: t, failed: true}
+	db.Cleanup(tb)
+	tb.runCleanups()
+
+	if !container.kept.Load() {
+		t.Errorf("SpannerContainer.kept = false, want true")
+	}
+
+	kept, err := keptDatabases()
+	if err != nil {
+		t.Fatalf("keptDatabases() error = %v", err)
+	}
+	if !kept[db.DatabaseName()] {
+		t.Errorf("keptDatabases() = %v, want %s recorded", kept, db.DatabaseName())
+	}
+
+	if _, err := container.CreateTestDatabase(ctx, "kept"); !errors.Is(err, ErrDatabaseAlreadyExists) {
+		t.Errorf("SpannerContainer.CreateTestDatabase() error = %v, want %v as the database was kept", err, ErrDatabaseAlreadyExists)
+	}
+}
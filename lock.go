@@ -0,0 +1,42 @@
+package dbinitiator
+
+import (
+	"context"
+
+	"github.com/go-playground/errors/v5"
+)
+
+// WithAdvisoryLock runs fn while holding the lock identified by key, so parallel subtests
+// sharing the database can serialize access to specific resources (sequences, singleton
+// rows, ...). Spanner has no advisory locks, so the lock is held in process and applies to
+// callers using the same SpannerDB.
+func (db *SpannerDB) WithAdvisoryLock(ctx context.Context, key int64, fn func(ctx context.Context) error) error {
+	lock := db.advisoryLock(key)
+
+	select {
+	case lock <- struct{}{}:
+	case <-ctx.Done():
+		return errors.Wrapf(ctx.Err(), "failed waiting for advisory lock %d", key)
+	}
+	defer func() { <-lock }()
+
+	return fn(ctx)
+}
+
+// advisoryLock returns the lock for key, creating it on first use
+func (db *SpannerDB) advisoryLock(key int64) chan struct{} {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.locks == nil {
+		db.locks = make(map[int64]chan struct{})
+	}
+
+	lock, ok := db.locks[key]
+	if !ok {
+		lock = make(chan struct{}, 1)
+		db.locks[key] = lock
+	}
+
+	return lock
+}
@@ -0,0 +1,75 @@
+package dbinitiator
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSpannerDB_WithAdvisoryLock(t *testing.T) {
+	t.Parallel()
+
+	db := &SpannerDB{}
+	ctx := context.Background()
+
+	var holders, maxHolders atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := db.WithAdvisoryLock(ctx, 1, func(context.Context) error {
+				n := holders.Add(1)
+				defer holders.Add(-1)
+				for {
+					m := maxHolders.Load()
+					if n <= m || maxHolders.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+
+				return nil
+			}); err != nil {
+				t.Errorf("SpannerDB.WithAdvisoryLock() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := maxHolders.Load(); got != 1 {
+		t.Errorf("max concurrent lock holders = %d, want 1", got)
+	}
+}
+
+func TestSpannerDB_WithAdvisoryLock_canceled(t *testing.T) {
+	t.Parallel()
+
+	db := &SpannerDB{}
+	ctx := context.Background()
+
+	held := make(chan struct{})
+	release := make(chan struct{})
+	go func() {
+		_ = db.WithAdvisoryLock(ctx, 1, func(context.Context) error {
+			close(held)
+			<-release
+
+			return nil
+		})
+	}()
+	<-held
+	defer close(release)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := db.WithAdvisoryLock(timeoutCtx, 1, func(context.Context) error { return nil }); err == nil {
+		t.Errorf("SpannerDB.WithAdvisoryLock() error = nil, want error when lock is held")
+	}
+
+	if err := db.WithAdvisoryLock(ctx, 2, func(context.Context) error { return nil }); err != nil {
+		t.Errorf("SpannerDB.WithAdvisoryLock() error = %v for a different key", err)
+	}
+}
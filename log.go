@@ -0,0 +1,11 @@
+package dbinitiator
+
+import (
+	"io"
+	"log/slog"
+)
+
+// discardLogger returns a logger that drops all records, used when no logger is configured
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
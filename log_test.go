@@ -0,0 +1,58 @@
+package dbinitiator
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use by a slog.Handler
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestWithLogger(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logs := &syncBuffer{}
+	container, err := NewSpannerContainer(ctx, "latest", WithLogger(slog.New(slog.NewTextHandler(logs, nil))))
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	db, err := container.CreateTestDatabase(ctx, t.Name())
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateTestDatabase() error = %v", err)
+	}
+	db.Cleanup(t)
+
+	if err := db.MigrateUp("file://testdata/migrations"); err != nil {
+		t.Fatalf("SpannerDB.MigrateUp() error = %v", err)
+	}
+
+	for _, want := range []string{"spanner emulator started", "created database", "migrated up"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("logs do not contain %q:\n%s", want, logs.String())
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package dbinitiator
+
+import "strings"
+
+// QuoteIdentifier quotes name for safe use as an identifier (database, table, column, ...)
+// in GoogleSQL statements.
+func QuoteIdentifier(name string) string {
+	return "`" + escape(name) + "`"
+}
+
+// QuoteString quotes s for safe use as a string literal in GoogleSQL statements. Prefer
+// query parameters where the statement supports them.
+func QuoteString(s string) string {
+	return `"` + escape(s) + `"`
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch r {
+		case '\\', '`', '"', '\'':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,78 @@
+package dbinitiator
+
+import "testing"
+
+func TestQuoteIdentifier(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "plain identifier",
+			in:   "Users",
+			want: "`Users`",
+		},
+		{
+			name: "reserved word",
+			in:   "Order",
+			want: "`Order`",
+		},
+		{
+			name: "backtick is escaped",
+			in:   "Us`ers",
+			want: "`Us\\`ers`",
+		},
+		{
+			name: "backslash is escaped",
+			in:   `Us\ers`,
+			want: "`Us\\\\ers`",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := QuoteIdentifier(tt.in); got != tt.want {
+				t.Errorf("QuoteIdentifier() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "plain string",
+			in:   "password",
+			want: `"password"`,
+		},
+		{
+			name: "quotes are escaped",
+			in:   `pa"ss'word`,
+			want: `"pa\"ss\'word"`,
+		},
+		{
+			name: "newline is escaped",
+			in:   "pass\nword",
+			want: `"pass\nword"`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := QuoteString(tt.in); got != tt.want {
+				t.Errorf("QuoteString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package dbinitiator
+
+import (
+	"context"
+	"slices"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/go-playground/errors/v5"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ReadOnlyClient returns a new client for the database that rejects every write with
+// codes.PermissionDenied: commits, batch writes, read-write and partitioned DML transactions
+// and batch DML all fail. A transaction is rejected when it begins, whether by
+// BeginTransaction or inline with its first statement, so no statement of a partitioned
+// update is sent. The emulator has no fine-grained access control, so the writes are rejected
+// by the client before they are sent. Use it to prove read paths never write. The caller
+// must close the client.
+func (db *SpannerDB) ReadOnlyClient(ctx context.Context) (*spanner.Client, error) {
+	opts := append(slices.Clip(db.opts),
+		option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(readOnlyUnaryInterceptor)),
+		option.WithGRPCDialOption(grpc.WithChainStreamInterceptor(readOnlyStreamInterceptor)),
+	)
+
+	client, err := spanner.NewClient(ctx, db.dbStr, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "spanner.NewClient()")
+	}
+
+	return client, nil
+}
+
+func readOnlyUnaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if err := checkReadOnly(method, req); err != nil {
+		return err
+	}
+
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+func readOnlyStreamInterceptor(
+	ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption,
+) (grpc.ClientStream, error) {
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &readOnlyStream{ClientStream: stream, method: method}, nil
+}
+
+// readOnlyStream rejects writes sent on a streaming call, such as ExecuteStreamingSql
+// beginning a read-write transaction
+type readOnlyStream struct {
+	grpc.ClientStream
+	method string
+}
+
+func (s *readOnlyStream) SendMsg(m any) error {
+	if err := checkReadOnly(s.method, m); err != nil {
+		return err
+	}
+
+	return s.ClientStream.SendMsg(m)
+}
+
+// checkReadOnly returns a PermissionDenied error if req, sent to method, writes to the database
+func checkReadOnly(method string, req any) error {
+	var begin *spannerpb.TransactionOptions
+	switch r := req.(type) {
+	case *spannerpb.CommitRequest, *spannerpb.ExecuteBatchDmlRequest, *spannerpb.BatchWriteRequest:
+		return status.Errorf(codes.PermissionDenied, "read-only client: %s is not allowed", method)
+	case *spannerpb.BeginTransactionRequest:
+		begin = r.GetOptions()
+	case *spannerpb.ExecuteSqlRequest:
+		begin = r.GetTransaction().GetBegin()
+	case *spannerpb.ReadRequest:
+		begin = r.GetTransaction().GetBegin()
+	default:
+		return nil
+	}
+
+	if begin.GetReadWrite() != nil || begin.GetPartitionedDml() != nil {
+		return status.Errorf(codes.PermissionDenied, "read-only client: %s can not begin a read-write transaction", method)
+	}
+
+	return nil
+}
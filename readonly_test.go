@@ -0,0 +1,116 @@
+package dbinitiator
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSpannerDB_ReadOnlyClient(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	db, err := container.CreateTestDatabase(ctx, t.Name())
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateTestDatabase() error = %v", err)
+	}
+	db.Cleanup(t)
+
+	if err := db.MigrateUp("file://testdata/migrations"); err != nil {
+		t.Fatalf("SpannerDB.MigrateUp() error = %v", err)
+	}
+
+	if _, err := db.Apply(ctx, []*spanner.Mutation{
+		spanner.Insert("Users", []string{"Id", "Username"}, []any{"1", "alice"}),
+	}); err != nil {
+		t.Fatalf("spanner.Client.Apply() error = %v", err)
+	}
+
+	client, err := db.ReadOnlyClient(ctx)
+	if err != nil {
+		t.Fatalf("SpannerDB.ReadOnlyClient() error = %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.Single().ReadRow(ctx, "Users", spanner.Key{"1"}, []string{"Username"}); err != nil {
+		t.Errorf("spanner.ReadOnlyTransaction.ReadRow() error = %v", err)
+	}
+
+	if _, err := client.Apply(ctx, []*spanner.Mutation{
+		spanner.Insert("Users", []string{"Id", "Username"}, []any{"2", "bob"}),
+	}); spanner.ErrCode(err) != codes.PermissionDenied {
+		t.Errorf("spanner.Client.Apply() error = %v, want PermissionDenied", err)
+	}
+
+	if _, err := client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		_, err := txn.Update(ctx, spanner.Statement{SQL: `DELETE FROM Users WHERE TRUE`})
+
+		return err
+	}); spanner.ErrCode(err) != codes.PermissionDenied {
+		t.Errorf("spanner.Client.ReadWriteTransaction() error = %v, want PermissionDenied", err)
+	}
+
+	if _, err := client.PartitionedUpdate(ctx, spanner.Statement{SQL: `DELETE FROM Users WHERE TRUE`}); spanner.ErrCode(err) != codes.PermissionDenied {
+		t.Errorf("spanner.Client.PartitionedUpdate() error = %v, want PermissionDenied", err)
+	}
+
+	if err := client.BatchWrite(ctx, []*spanner.MutationGroup{{Mutations: []*spanner.Mutation{
+		spanner.Insert("Users", []string{"Id", "Username"}, []any{"3", "carol"}),
+	}}}).Do(func(*spannerpb.BatchWriteResponse) error { return nil }); spanner.ErrCode(err) != codes.PermissionDenied {
+		t.Errorf("spanner.Client.BatchWrite() error = %v, want PermissionDenied", err)
+	}
+
+	if _, err := db.Single().ReadRow(ctx, "Users", spanner.Key{"3"}, []string{"Username"}); spanner.ErrCode(err) != codes.NotFound {
+		t.Errorf("spanner.ReadOnlyTransaction.ReadRow() error = %v, want NotFound for a row written by the read-only client", err)
+	}
+}
+
+func Test_checkReadOnly(t *testing.T) {
+	t.Parallel()
+
+	readWrite := &spannerpb.TransactionOptions{Mode: &spannerpb.TransactionOptions_ReadWrite_{ReadWrite: &spannerpb.TransactionOptions_ReadWrite{}}}
+	partitioned := &spannerpb.TransactionOptions{Mode: &spannerpb.TransactionOptions_PartitionedDml_{PartitionedDml: &spannerpb.TransactionOptions_PartitionedDml{}}}
+	readOnly := &spannerpb.TransactionOptions{Mode: &spannerpb.TransactionOptions_ReadOnly_{ReadOnly: &spannerpb.TransactionOptions_ReadOnly{}}}
+	tests := []struct {
+		name    string
+		req     any
+		wantErr bool
+	}{
+		{name: "query", req: &spannerpb.ExecuteSqlRequest{Sql: "SELECT 1"}},
+		{name: "read-only begin", req: &spannerpb.BeginTransactionRequest{Options: readOnly}},
+		{name: "commit", req: &spannerpb.CommitRequest{}, wantErr: true},
+		{name: "batch dml", req: &spannerpb.ExecuteBatchDmlRequest{}, wantErr: true},
+		{name: "batch write", req: &spannerpb.BatchWriteRequest{}, wantErr: true},
+		{name: "read-write begin", req: &spannerpb.BeginTransactionRequest{Options: readWrite}, wantErr: true},
+		{name: "partitioned dml begin", req: &spannerpb.BeginTransactionRequest{Options: partitioned}, wantErr: true},
+		{
+			name:    "partitioned dml inline begin",
+			req:     &spannerpb.ExecuteSqlRequest{Transaction: &spannerpb.TransactionSelector{Selector: &spannerpb.TransactionSelector_Begin{Begin: partitioned}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := checkReadOnly("/google.spanner.v1.Spanner/Method", tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkReadOnly() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && status.Code(err) != codes.PermissionDenied {
+				t.Errorf("checkReadOnly() code = %v, want %v", status.Code(err), codes.PermissionDenied)
+			}
+		})
+	}
+}
@@ -0,0 +1,792 @@
+package dbinitiator
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/go-playground/errors/v5"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RecordedStatement is a request captured by RecordingClient, written as one JSON line. It
+// holds either a SQL statement, a read by key or the mutations of a commit.
+type RecordedStatement struct {
+	Time   time.Time                `json:"time"`
+	SQL    string                   `json:"sql,omitempty"`
+	Params map[string]RecordedParam `json:"params,omitempty"`
+
+	// Read is set for reads by key, such as ReadRow
+	Read *RecordedRead `json:"read,omitempty"`
+
+	// Mutations are set for the mutations of a commit, such as those passed to Apply or
+	// BufferWrite
+	Mutations []RecordedMutation `json:"mutations,omitempty"`
+
+	// DML is set for statements sent as DML, such as those passed to Update or BatchUpdate
+	DML bool `json:"dml,omitempty"`
+}
+
+// RecordedParam is a statement parameter in its Spanner wire representation, e.g. INT64
+// values are strings and BYTES values are base64 encoded
+type RecordedParam struct {
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+// RecordedRead is a read of rows by key
+type RecordedRead struct {
+	Table   string         `json:"table"`
+	Index   string         `json:"index,omitempty"`
+	Columns []string       `json:"columns"`
+	KeySet  RecordedKeySet `json:"keySet"`
+	Limit   int64          `json:"limit,omitempty"`
+}
+
+// RecordedMutation is a mutation in its Spanner wire representation. Op is one of INSERT,
+// UPDATE, INSERT_OR_UPDATE, REPLACE or DELETE. Rows are set for writes, KeySet for deletes.
+type RecordedMutation struct {
+	Op      string          `json:"op"`
+	Table   string          `json:"table"`
+	Columns []string        `json:"columns,omitempty"`
+	Rows    [][]any         `json:"rows,omitempty"`
+	KeySet  *RecordedKeySet `json:"keySet,omitempty"`
+}
+
+// RecordedKeySet is a set of keys in their Spanner wire representation
+type RecordedKeySet struct {
+	All    bool               `json:"all,omitempty"`
+	Keys   [][]any            `json:"keys,omitempty"`
+	Ranges []RecordedKeyRange `json:"ranges,omitempty"`
+}
+
+// RecordedKeyRange is a range of keys, see spanner.KeyRange
+type RecordedKeyRange struct {
+	Start       []any `json:"start"`
+	StartClosed bool  `json:"startClosed"`
+	End         []any `json:"end"`
+	EndClosed   bool  `json:"endClosed"`
+}
+
+// invalidFileChars matches characters not used in recording file names
+var invalidFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// RecordingClient returns a new client for the database that records the SQL statements,
+// reads and committed mutations sent through it, with their parameters, to a file per test
+// in dir: a flight recorder for debugging failures and building load profiles. Only requests
+// sent through the returned client are recorded; db.Client and other clients are not, so
+// the code under test must be given this client. Each request is a JSON encoded
+// RecordedStatement on its own line. Requests of a transaction are written when it ends,
+// and those of attempts aborted and retried by the client are left out. Replay the file
+// with ReplayStatements. The client is closed when the test finishes.
+func (db *SpannerDB) RecordingClient(tb TB, dir string) *spanner.Client {
+	tb.Helper()
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		tb.Fatalf("os.MkdirAll() error = %v", err)
+	}
+
+	path := filepath.Join(dir, invalidFileChars.ReplaceAllString(tb.Name(), "_")+".jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		tb.Fatalf("os.Create() error = %v", err)
+	}
+
+	r := newRecorder(f)
+	opts := append(slices.Clip(db.opts),
+		option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(r.unaryInterceptor)),
+		option.WithGRPCDialOption(grpc.WithChainStreamInterceptor(r.streamInterceptor)),
+	)
+
+	client, err := spanner.NewClient(context.Background(), db.dbStr, opts...)
+	if err != nil {
+		_ = f.Close()
+		tb.Fatalf("spanner.NewClient() error = %v", err)
+	}
+
+	tb.Cleanup(func() {
+		client.Close()
+		r.flushAll()
+		if err := r.err(); err != nil {
+			tb.Errorf("failed to record statements to %s: %v", path, err)
+		}
+		if err := f.Close(); err != nil {
+			tb.Errorf("os.File.Close() error = %v", err)
+		}
+	})
+
+	return client
+}
+
+// recorder writes the requests sent through its interceptors. Requests made in a transaction
+// are held per session until the transaction ends, and dropped if it is aborted, since the
+// client retries the whole transaction.
+type recorder struct {
+	mu       sync.Mutex
+	enc      *json.Encoder
+	pending  map[string][]RecordedStatement
+	firstErr error
+}
+
+func newRecorder(w io.Writer) *recorder {
+	return &recorder{enc: json.NewEncoder(w), pending: make(map[string][]RecordedStatement)}
+}
+
+func (r *recorder) unaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now().UTC()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	if aborted(err, reply) {
+		r.discard(session(req))
+	} else {
+		r.record(start, req, true)
+	}
+
+	return err
+}
+
+func (r *recorder) streamInterceptor(
+	ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption,
+) (grpc.ClientStream, error) {
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &recordingStream{ClientStream: stream, recorder: r}, nil
+}
+
+// recordingStream records the requests sent on a streaming call, such as ExecuteStreamingSql
+type recordingStream struct {
+	grpc.ClientStream
+	recorder *recorder
+	session  string
+}
+
+func (s *recordingStream) SendMsg(m any) error {
+	s.session = session(m)
+	s.recorder.record(time.Now().UTC(), m, false)
+
+	return s.ClientStream.SendMsg(m)
+}
+
+func (s *recordingStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if aborted(err, nil) {
+		s.recorder.discard(s.session)
+	}
+
+	return err //nolint:wrapcheck // the stream error is returned to the spanner client as is
+}
+
+// aborted reports whether a request failed with codes.Aborted, which makes the client retry
+// the transaction
+func aborted(err error, reply any) bool {
+	if resp, ok := reply.(*spannerpb.ExecuteBatchDmlResponse); ok && resp.GetStatus().GetCode() == int32(codes.Aborted) {
+		return true
+	}
+
+	return status.Code(err) == codes.Aborted
+}
+
+// session returns the session a request is sent on
+func session(req any) string {
+	if req, ok := req.(interface{ GetSession() string }); ok {
+		return req.GetSession()
+	}
+
+	return ""
+}
+
+// record adds the statements, reads and mutations in req. Requests resuming an interrupted
+// stream are not recorded again. The spanner client sends DML with the unary ExecuteSql and
+// queries with ExecuteStreamingSql, so statements sent on a unary call are recorded as DML.
+func (r *recorder) record(start time.Time, req any, unary bool) {
+	switch req := req.(type) {
+	case *spannerpb.ExecuteSqlRequest:
+		if len(req.GetResumeToken()) == 0 {
+			stmt := statement(start, req.GetSql(), req.GetParams().AsMap(), req.GetParamTypes())
+			stmt.DML = unary
+			r.add(req.GetSession(), inTransaction(req.GetTransaction()), stmt)
+		}
+	case *spannerpb.ExecuteBatchDmlRequest:
+		for _, s := range req.GetStatements() {
+			stmt := statement(start, s.GetSql(), s.GetParams().AsMap(), s.GetParamTypes())
+			stmt.DML = true
+			r.add(req.GetSession(), inTransaction(req.GetTransaction()), stmt)
+		}
+	case *spannerpb.ReadRequest:
+		if len(req.GetResumeToken()) == 0 {
+			r.add(req.GetSession(), inTransaction(req.GetTransaction()), RecordedStatement{Time: start, Read: &RecordedRead{
+				Table:   req.GetTable(),
+				Index:   req.GetIndex(),
+				Columns: req.GetColumns(),
+				KeySet:  recordedKeySet(req.GetKeySet()),
+				Limit:   req.GetLimit(),
+			}})
+		}
+	case *spannerpb.BeginTransactionRequest:
+		// The session starts a new transaction, so the previous one has ended
+		r.flush(req.GetSession())
+	case *spannerpb.CommitRequest:
+		if mutations := req.GetMutations(); len(mutations) > 0 {
+			r.add(req.GetSession(), true, RecordedStatement{Time: start, Mutations: recordedMutations(mutations)})
+		}
+		r.flush(req.GetSession())
+	case *spannerpb.RollbackRequest:
+		r.flush(req.GetSession())
+	default:
+	}
+}
+
+func statement(start time.Time, sql string, params map[string]any, types map[string]*spannerpb.Type) RecordedStatement {
+	stmt := RecordedStatement{Time: start, SQL: sql}
+	if len(params) > 0 {
+		stmt.Params = make(map[string]RecordedParam, len(params))
+		for name, value := range params {
+			stmt.Params[name] = RecordedParam{Type: typeName(types[name]), Value: value}
+		}
+	}
+
+	return stmt
+}
+
+func recordedMutations(mutations []*spannerpb.Mutation) []RecordedMutation {
+	recorded := make([]RecordedMutation, 0, len(mutations))
+	for _, m := range mutations {
+		if d := m.GetDelete(); d != nil {
+			ks := recordedKeySet(d.GetKeySet())
+			recorded = append(recorded, RecordedMutation{Op: "DELETE", Table: d.GetTable(), KeySet: &ks})
+
+			continue
+		}
+
+		var op string
+		var w *spannerpb.Mutation_Write
+		switch {
+		case m.GetInsert() != nil:
+			op, w = "INSERT", m.GetInsert()
+		case m.GetUpdate() != nil:
+			op, w = "UPDATE", m.GetUpdate()
+		case m.GetInsertOrUpdate() != nil:
+			op, w = "INSERT_OR_UPDATE", m.GetInsertOrUpdate()
+		default:
+			op, w = "REPLACE", m.GetReplace()
+		}
+
+		rm := RecordedMutation{Op: op, Table: w.GetTable(), Columns: w.GetColumns()}
+		for _, row := range w.GetValues() {
+			rm.Rows = append(rm.Rows, row.AsSlice())
+		}
+		recorded = append(recorded, rm)
+	}
+
+	return recorded
+}
+
+func recordedKeySet(ks *spannerpb.KeySet) RecordedKeySet {
+	recorded := RecordedKeySet{All: ks.GetAll()}
+	for _, key := range ks.GetKeys() {
+		recorded.Keys = append(recorded.Keys, key.AsSlice())
+	}
+	for _, kr := range ks.GetRanges() {
+		rkr := RecordedKeyRange{StartClosed: kr.GetStartClosed() != nil, EndClosed: kr.GetEndClosed() != nil}
+		if rkr.StartClosed {
+			rkr.Start = kr.GetStartClosed().AsSlice()
+		} else {
+			rkr.Start = kr.GetStartOpen().AsSlice()
+		}
+		if rkr.EndClosed {
+			rkr.End = kr.GetEndClosed().AsSlice()
+		} else {
+			rkr.End = kr.GetEndOpen().AsSlice()
+		}
+		recorded.Ranges = append(recorded.Ranges, rkr)
+	}
+
+	return recorded
+}
+
+// inTransaction reports whether a request is part of a multi-use transaction
+func inTransaction(txn *spannerpb.TransactionSelector) bool {
+	return txn.GetId() != nil || txn.GetBegin() != nil
+}
+
+// add writes stmt, or holds it until the transaction ends if it is part of one
+func (r *recorder) add(session string, inTxn bool, stmt RecordedStatement) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if inTxn {
+		r.pending[session] = append(r.pending[session], stmt)
+
+		return
+	}
+
+	r.flushLocked(session)
+	r.write(stmt)
+}
+
+// flush writes the statements held for the transaction on session
+func (r *recorder) flush(session string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.flushLocked(session)
+}
+
+func (r *recorder) flushLocked(session string) {
+	for _, stmt := range r.pending[session] {
+		r.write(stmt)
+	}
+	delete(r.pending, session)
+}
+
+// flushAll writes the statements of transactions that did not end, such as read-only
+// transactions, which are not committed
+func (r *recorder) flushAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sessions := make([]string, 0, len(r.pending))
+	for session := range r.pending {
+		sessions = append(sessions, session)
+	}
+	slices.Sort(sessions)
+
+	for _, session := range sessions {
+		r.flushLocked(session)
+	}
+}
+
+// discard drops the statements held for the aborted transaction on session
+func (r *recorder) discard(session string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.pending, session)
+}
+
+func (r *recorder) write(stmt RecordedStatement) {
+	if err := r.enc.Encode(stmt); err != nil && r.firstErr == nil {
+		r.firstErr = errors.Wrap(err, "json.Encoder.Encode()")
+	}
+}
+
+func (r *recorder) err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.firstErr
+}
+
+// typeName returns the name of t as used in DDL, e.g. ARRAY<INT64>
+func typeName(t *spannerpb.Type) string {
+	if t.GetCode() == spannerpb.TypeCode_ARRAY {
+		return "ARRAY<" + typeName(t.GetArrayElementType()) + ">"
+	}
+
+	return t.GetCode().String()
+}
+
+// ReplayStatements executes the statements recorded by RecordingClient in the file at path
+// against client, in order. Queries and reads are read to completion and discarded, DML
+// statements each run in their own read-write transaction, and
+// the mutations of each commit are applied together. Statement parameters of ARRAY and
+// STRUCT types can not be replayed.
+func ReplayStatements(ctx context.Context, client *spanner.Client, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "os.Open()")
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 10*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var rec RecordedStatement
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return errors.Wrapf(err, "invalid recording %s:%d", path, line)
+		}
+
+		if err := replayStatement(ctx, client, rec); err != nil {
+			return errors.Wrapf(err, "failed to replay %s:%d", path, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrap(err, "bufio.Scanner.Scan()")
+	}
+
+	return nil
+}
+
+func replayStatement(ctx context.Context, client *spanner.Client, rec RecordedStatement) error {
+	switch {
+	case rec.Read != nil:
+		return replayRead(ctx, client, rec.Read)
+	case len(rec.Mutations) > 0:
+		return replayMutations(ctx, client, rec.Mutations)
+	}
+
+	stmt := spanner.Statement{SQL: rec.SQL, Params: make(map[string]any, len(rec.Params))}
+	for name, p := range rec.Params {
+		v, err := decodeParam(p)
+		if err != nil {
+			return errors.Wrapf(err, "parameter %s", name)
+		}
+		stmt.Params[name] = v
+	}
+
+	if rec.DML || isDML(rec.SQL) {
+		if _, err := client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+			if _, err := txn.Update(ctx, stmt); err != nil {
+				return errors.Wrap(err, "spanner.ReadWriteTransaction.Update()")
+			}
+
+			return nil
+		}); err != nil {
+			return errors.Wrap(err, "spanner.Client.ReadWriteTransaction()")
+		}
+
+		return nil
+	}
+
+	if err := client.Single().Query(ctx, stmt).Do(func(*spanner.Row) error { return nil }); err != nil {
+		return errors.Wrap(err, "spanner.RowIterator.Do()")
+	}
+
+	return nil
+}
+
+// isDML reports whether sql is an INSERT, UPDATE or DELETE statement. Leading comments and
+// statement hints are skipped. It classifies statements recorded without the DML flag, such
+// as hand-written recordings, where DML starting with a WITH clause is replayed as a query.
+func isDML(sql string) bool {
+	for {
+		sql = strings.TrimSpace(sql)
+		var end string
+		switch {
+		case strings.HasPrefix(sql, "--"), strings.HasPrefix(sql, "#"):
+			end = "\n"
+		case strings.HasPrefix(sql, "/*"):
+			end = "*/"
+		case strings.HasPrefix(sql, "@{"):
+			end = "}"
+		default:
+			n := strings.IndexFunc(sql, func(r rune) bool { return !unicode.IsLetter(r) })
+			if n < 0 {
+				n = len(sql)
+			}
+			switch strings.ToUpper(sql[:n]) {
+			case "INSERT", "UPDATE", "DELETE":
+				return true
+			}
+
+			return false
+		}
+
+		_, rest, found := strings.Cut(sql, end)
+		if !found {
+			return false
+		}
+		sql = rest
+	}
+}
+
+func replayRead(ctx context.Context, client *spanner.Client, read *RecordedRead) error {
+	keys, err := read.KeySet.keySet()
+	if err != nil {
+		return err
+	}
+
+	opts := &spanner.ReadOptions{Index: read.Index, Limit: int(read.Limit)}
+	if err := client.Single().ReadWithOptions(ctx, read.Table, keys, read.Columns, opts).Do(func(*spanner.Row) error { return nil }); err != nil {
+		return errors.Wrap(err, "spanner.RowIterator.Do()")
+	}
+
+	return nil
+}
+
+func replayMutations(ctx context.Context, client *spanner.Client, recorded []RecordedMutation) error {
+	var mutations []*spanner.Mutation
+	for _, m := range recorded {
+		if m.Op == "DELETE" {
+			if m.KeySet == nil {
+				return errors.Newf("DELETE mutation of %s has no key set", m.Table)
+			}
+			keys, err := m.KeySet.keySet()
+			if err != nil {
+				return err
+			}
+			mutations = append(mutations, spanner.Delete(m.Table, keys))
+
+			continue
+		}
+
+		var write func(table string, columns []string, values []any) *spanner.Mutation
+		switch m.Op {
+		case "INSERT":
+			write = spanner.Insert
+		case "UPDATE":
+			write = spanner.Update
+		case "INSERT_OR_UPDATE":
+			write = spanner.InsertOrUpdate
+		case "REPLACE":
+			write = spanner.Replace
+		default:
+			return errors.Newf("unsupported mutation %s", m.Op)
+		}
+
+		for _, row := range m.Rows {
+			values := make([]any, 0, len(row))
+			for _, v := range row {
+				value, err := wireValue(v)
+				if err != nil {
+					return errors.Wrapf(err, "%s mutation of %s", m.Op, m.Table)
+				}
+				values = append(values, value)
+			}
+			mutations = append(mutations, write(m.Table, m.Columns, values))
+		}
+	}
+
+	if _, err := client.Apply(ctx, mutations); err != nil {
+		return errors.Wrap(err, "spanner.Client.Apply()")
+	}
+
+	return nil
+}
+
+// keySet converts ks into a spanner.KeySet
+func (ks RecordedKeySet) keySet() (spanner.KeySet, error) {
+	if ks.All {
+		return spanner.AllKeys(), nil
+	}
+
+	sets := make([]spanner.KeySet, 0, len(ks.Keys)+len(ks.Ranges))
+	for _, k := range ks.Keys {
+		key, err := wireKey(k)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, key)
+	}
+	for _, r := range ks.Ranges {
+		start, err := wireKey(r.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := wireKey(r.End)
+		if err != nil {
+			return nil, err
+		}
+
+		kind := spanner.OpenOpen
+		switch {
+		case r.StartClosed && r.EndClosed:
+			kind = spanner.ClosedClosed
+		case r.StartClosed:
+			kind = spanner.ClosedOpen
+		case r.EndClosed:
+			kind = spanner.OpenClosed
+		}
+		sets = append(sets, spanner.KeyRange{Start: start, End: end, Kind: kind})
+	}
+
+	return spanner.KeySets(sets...), nil
+}
+
+// wireKey converts the parts of a recorded key into a spanner.Key
+func wireKey(parts []any) (spanner.Key, error) {
+	key := make(spanner.Key, 0, len(parts))
+	for _, part := range parts {
+		if _, ok := part.([]any); ok {
+			return nil, errors.Newf("invalid key part %v", part)
+		}
+		v, err := wireValue(part)
+		if err != nil {
+			return nil, err
+		}
+		key = append(key, v)
+	}
+
+	return key, nil
+}
+
+// wireValue converts a value in its wire representation, as decoded from JSON, into a value
+// the spanner client encodes the same way. Mutations and keys carry no types, Spanner decodes
+// them using the column types, so e.g. an INT64 is sent as the string it was recorded as.
+func wireValue(v any) (any, error) {
+	switch v := v.(type) {
+	case nil:
+		return spanner.NullString{}, nil
+	case string, float64, bool:
+		return v, nil
+	case []any:
+		return wireArray(v)
+	default:
+		return nil, errors.Newf("unsupported value %v", v)
+	}
+}
+
+// wireArray converts an array value, whose elements are all strings, numbers or bools
+func wireArray(elems []any) (any, error) {
+	var kind any
+	for _, e := range elems {
+		if e != nil {
+			kind = e
+
+			break
+		}
+	}
+
+	switch kind.(type) {
+	case nil, string:
+		return wireSlice(elems, func(s string) spanner.NullString { return spanner.NullString{StringVal: s, Valid: true} })
+	case float64:
+		return wireSlice(elems, func(f float64) spanner.NullFloat64 { return spanner.NullFloat64{Float64: f, Valid: true} })
+	case bool:
+		return wireSlice(elems, func(b bool) spanner.NullBool { return spanner.NullBool{Bool: b, Valid: true} })
+	default:
+		return nil, errors.Newf("unsupported array value %v", elems)
+	}
+}
+
+func wireSlice[E, T any](elems []any, valid func(E) T) (any, error) {
+	s := make([]T, 0, len(elems))
+	for _, e := range elems {
+		if e == nil {
+			var null T
+			s = append(s, null)
+
+			continue
+		}
+		v, ok := e.(E)
+		if !ok {
+			return nil, errors.Newf("mixed array element %v", e)
+		}
+		s = append(s, valid(v))
+	}
+
+	return s, nil
+}
+
+// decodeParam converts a recorded parameter from its wire representation into a value the
+// spanner client encodes with the same type
+func decodeParam(p RecordedParam) (any, error) {
+	s, isString := p.Value.(string)
+	switch p.Type {
+	case "BOOL":
+		if p.Value == nil {
+			return spanner.NullBool{}, nil
+		}
+		if b, ok := p.Value.(bool); ok {
+			return b, nil
+		}
+	case "INT64":
+		if p.Value == nil {
+			return spanner.NullInt64{}, nil
+		}
+		if isString {
+			i, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, errors.Wrap(err, "strconv.ParseInt()")
+			}
+
+			return i, nil
+		}
+	case "FLOAT64":
+		if p.Value == nil {
+			return spanner.NullFloat64{}, nil
+		}
+		if f, ok := p.Value.(float64); ok {
+			return f, nil
+		}
+	case "FLOAT32":
+		if p.Value == nil {
+			return spanner.NullFloat32{}, nil
+		}
+		if f, ok := p.Value.(float64); ok {
+			return float32(f), nil
+		}
+	case "STRING":
+		if p.Value == nil || isString {
+			return spanner.NullString{StringVal: s, Valid: p.Value != nil}, nil
+		}
+	case "BYTES":
+		if p.Value == nil {
+			return []byte(nil), nil
+		}
+		if isString {
+			b, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return nil, errors.Wrap(err, "base64.Encoding.DecodeString()")
+			}
+
+			return b, nil
+		}
+	case "DATE", "TIMESTAMP", "NUMERIC", "JSON":
+		return decodeNullParam(p)
+	default:
+		return nil, errors.Newf("unsupported parameter type %s", p.Type)
+	}
+
+	return nil, errors.Newf("invalid %s value %v", p.Type, p.Value)
+}
+
+// decodeNullParam decodes DATE, TIMESTAMP, NUMERIC and JSON parameters using the JSON
+// decoding of the spanner Null types, which matches their wire representation
+func decodeNullParam(p RecordedParam) (any, error) {
+	b, err := json.Marshal(p.Value)
+	if err != nil {
+		return nil, errors.Wrap(err, "json.Marshal()")
+	}
+
+	var v any
+	switch p.Type {
+	case "DATE":
+		v, err = unmarshalNull[spanner.NullDate](b)
+	case "TIMESTAMP":
+		v, err = unmarshalNull[spanner.NullTime](b)
+	case "NUMERIC":
+		v, err = unmarshalNull[spanner.NullNumeric](b)
+	default:
+		// JSON values are sent as a string holding the JSON document
+		if s, ok := p.Value.(string); ok {
+			b = []byte(s)
+		}
+		v, err = unmarshalNull[spanner.NullJSON](b)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s value %v", p.Type, p.Value)
+	}
+
+	return v, nil
+}
+
+func unmarshalNull[T any, PT interface {
+	*T
+	UnmarshalJSON(b []byte) error
+}](b []byte) (T, error) {
+	var v T
+	if err := PT(&v).UnmarshalJSON(b); err != nil {
+		return v, errors.Wrap(err, "UnmarshalJSON()")
+	}
+
+	return v, nil
+}
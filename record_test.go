@@ -0,0 +1,308 @@
+package dbinitiator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_typeName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		typ  *spannerpb.Type
+		want string
+	}{
+		{name: "scalar", typ: &spannerpb.Type{Code: spannerpb.TypeCode_INT64}, want: "INT64"},
+		{
+			name: "array",
+			typ:  &spannerpb.Type{Code: spannerpb.TypeCode_ARRAY, ArrayElementType: &spannerpb.Type{Code: spannerpb.TypeCode_STRING}},
+			want: "ARRAY<STRING>",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := typeName(tt.typ); got != tt.want {
+				t.Errorf("typeName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_decodeParam(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		param   RecordedParam
+		want    any
+		wantErr bool
+	}{
+		{name: "INT64", param: RecordedParam{Type: "INT64", Value: "42"}, want: int64(42)},
+		{name: "NULL INT64", param: RecordedParam{Type: "INT64"}, want: spanner.NullInt64{}},
+		{name: "STRING", param: RecordedParam{Type: "STRING", Value: "alice"}, want: spanner.NullString{StringVal: "alice", Valid: true}},
+		{name: "BOOL", param: RecordedParam{Type: "BOOL", Value: true}, want: true},
+		{name: "FLOAT64", param: RecordedParam{Type: "FLOAT64", Value: 1.5}, want: 1.5},
+		{name: "FLOAT32", param: RecordedParam{Type: "FLOAT32", Value: 1.5}, want: float32(1.5)},
+		{name: "NULL FLOAT32", param: RecordedParam{Type: "FLOAT32"}, want: spanner.NullFloat32{}},
+		{name: "invalid FLOAT32", param: RecordedParam{Type: "FLOAT32", Value: "1.5"}, wantErr: true},
+		{name: "BYTES", param: RecordedParam{Type: "BYTES", Value: "aGk="}, want: []byte("hi")},
+		{name: "JSON", param: RecordedParam{Type: "JSON", Value: `{"a":1}`}, want: spanner.NullJSON{Value: map[string]any{"a": float64(1)}, Valid: true}},
+		{name: "NULL DATE", param: RecordedParam{Type: "DATE"}, want: spanner.NullDate{}},
+		{name: "invalid INT64", param: RecordedParam{Type: "INT64", Value: "x"}, wantErr: true},
+		{name: "wrong value type", param: RecordedParam{Type: "BOOL", Value: "true"}, wantErr: true},
+		{name: "array", param: RecordedParam{Type: "ARRAY<INT64>", Value: []any{"1"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := decodeParam(tt.param)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeParam() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeParam() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpannerDB_RecordingClient(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	newDB := func(name string) *SpannerDB {
+		db, err := container.CreateTestDatabase(ctx, name)
+		if err != nil {
+			t.Fatalf("SpannerContainer.CreateTestDatabase() error = %v", err)
+		}
+		db.Cleanup(t)
+
+		if err := db.MigrateUp("file://testdata/migrations"); err != nil {
+			t.Fatalf("SpannerDB.MigrateUp() error = %v", err)
+		}
+
+		return db
+	}
+	db := newDB("record-source")
+	replayDB := newDB("record-replay")
+
+	dir := t.TempDir()
+	t.Run("record", func(t *testing.T) {
+		client := db.RecordingClient(t, dir)
+		if _, err := client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+			_, err := txn.Update(ctx, spanner.Statement{
+				SQL:    `INSERT INTO Users (Id, Username) VALUES (@id, @username)`,
+				Params: map[string]any{"id": "1", "username": "alice"},
+			})
+
+			return err
+		}); err != nil {
+			t.Fatalf("spanner.Client.ReadWriteTransaction() error = %v", err)
+		}
+
+		if err := client.Single().Query(ctx, spanner.Statement{
+			SQL:    `SELECT Username FROM Users WHERE Id = @id`,
+			Params: map[string]any{"id": "1"},
+		}).Do(func(*spanner.Row) error { return nil }); err != nil {
+			t.Fatalf("spanner.RowIterator.Do() error = %v", err)
+		}
+
+		if _, err := client.Apply(ctx, []*spanner.Mutation{
+			spanner.Insert("Users", []string{"Id", "Username"}, []any{"2", "bob"}),
+		}); err != nil {
+			t.Fatalf("spanner.Client.Apply() error = %v", err)
+		}
+
+		if _, err := client.Single().ReadRow(ctx, "Users", spanner.Key{"2"}, []string{"Username"}); err != nil {
+			t.Fatalf("spanner.ReadOnlyTransaction.ReadRow() error = %v", err)
+		}
+	})
+
+	path := filepath.Join(dir, "TestSpannerDB_RecordingClient_record.jsonl")
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("os.Open() error = %v", err)
+	}
+	defer f.Close()
+
+	var recorded []RecordedStatement
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec RecordedStatement
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+		recorded = append(recorded, rec)
+	}
+	if len(recorded) != 4 {
+		t.Fatalf("recorded %d statements, want 4: %v", len(recorded), recorded)
+	}
+	if got, want := recorded[0].Params["username"], (RecordedParam{Type: "STRING", Value: "alice"}); got != want {
+		t.Errorf("recorded parameter = %v, want %v", got, want)
+	}
+	if !recorded[0].DML || recorded[1].DML {
+		t.Errorf("recorded DML = %v, %v, want true, false", recorded[0].DML, recorded[1].DML)
+	}
+	if got := recorded[2].Mutations; len(got) != 1 || got[0].Op != "INSERT" || got[0].Table != "Users" {
+		t.Errorf("recorded mutations = %v, want an INSERT into Users", got)
+	}
+	if got := recorded[3].Read; got == nil || got.Table != "Users" || !reflect.DeepEqual(got.KeySet.Keys, [][]any{{"2"}}) {
+		t.Errorf("recorded read = %v, want a read of key 2 from Users", got)
+	}
+
+	if err := ReplayStatements(ctx, replayDB.Client, path); err != nil {
+		t.Fatalf("ReplayStatements() error = %v", err)
+	}
+
+	for id, want := range map[string]string{"1": "alice", "2": "bob"} {
+		row, err := replayDB.Single().ReadRow(ctx, "Users", spanner.Key{id}, []string{"Username"})
+		if err != nil {
+			t.Fatalf("spanner.ReadOnlyTransaction.ReadRow() error = %v", err)
+		}
+		var username string
+		if err := row.Column(0, &username); err != nil {
+			t.Fatalf("spanner.Row.Column() error = %v", err)
+		}
+		if username != want {
+			t.Errorf("replayed Username = %v, want %v", username, want)
+		}
+	}
+}
+
+func Test_recorder(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var b bytes.Buffer
+	r := newRecorder(&b)
+	call := func(req any, err error) {
+		_ = r.unaryInterceptor(ctx, "", req, nil, nil, func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+			return err
+		})
+	}
+
+	txn := &spannerpb.TransactionSelector{Selector: &spannerpb.TransactionSelector_Id{Id: []byte("txn")}}
+	deleteAll := &spannerpb.Mutation{Operation: &spannerpb.Mutation_Delete_{
+		Delete: &spannerpb.Mutation_Delete{Table: "Users", KeySet: &spannerpb.KeySet{All: true}},
+	}}
+
+	// The first attempt of the transaction is aborted and retried by the client
+	call(&spannerpb.ExecuteSqlRequest{Session: "s1", Transaction: txn, Sql: "UPDATE Users SET Email = NULL WHERE TRUE"}, nil)
+	call(&spannerpb.CommitRequest{Session: "s1", Mutations: []*spannerpb.Mutation{deleteAll}}, status.Error(codes.Aborted, "aborted"))
+	call(&spannerpb.ExecuteSqlRequest{Session: "s1", Transaction: txn, Sql: "UPDATE Users SET Email = NULL WHERE TRUE"}, nil)
+	// Queries are sent on a stream
+	r.record(time.Now().UTC(), &spannerpb.ExecuteSqlRequest{Session: "s2", Sql: "SELECT 1"}, false)
+	call(&spannerpb.CommitRequest{Session: "s1", Mutations: []*spannerpb.Mutation{deleteAll}}, nil)
+	r.flushAll()
+
+	var got []string
+	dec := json.NewDecoder(&b)
+	for dec.More() {
+		var rec RecordedStatement
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatalf("json.Decoder.Decode() error = %v", err)
+		}
+		if rec.DML {
+			got = append(got, "DML "+rec.SQL)
+		} else if rec.SQL != "" {
+			got = append(got, rec.SQL)
+		}
+		for _, m := range rec.Mutations {
+			got = append(got, m.Op+" "+m.Table)
+		}
+	}
+
+	want := []string{"SELECT 1", "DML UPDATE Users SET Email = NULL WHERE TRUE", "DELETE Users"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("recorded %v, want %v", got, want)
+	}
+}
+
+func Test_isDML(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		sql  string
+		want bool
+	}{
+		{name: "insert", sql: "INSERT INTO Users (ID) VALUES (1)", want: true},
+		{name: "lower case", sql: "  update Users SET Name = 'a' WHERE TRUE", want: true},
+		{name: "newline after keyword", sql: "DELETE\nFROM Users WHERE TRUE", want: true},
+		{name: "statement hint", sql: "@{PDML_MAX_PARALLELISM=1} DELETE FROM Users WHERE TRUE", want: true},
+		{name: "line comment", sql: "-- clear users\nDELETE FROM Users WHERE TRUE", want: true},
+		{name: "hash comment", sql: "# clear users\nDELETE FROM Users WHERE TRUE", want: true},
+		{name: "block comment and hint", sql: "/* clear */ @{LOCK_SCANNED_RANGES=exclusive} UPDATE Users SET Name = 'a' WHERE TRUE", want: true},
+		{name: "query", sql: "SELECT * FROM Users"},
+		{name: "query with hint", sql: "@{USE_ADDITIONAL_PARALLELISM=TRUE} SELECT * FROM Users"},
+		{name: "with clause", sql: "WITH u AS (SELECT 1) SELECT * FROM u"},
+		{name: "keyword prefix", sql: "INSERTED"},
+		{name: "unterminated comment", sql: "/* DELETE FROM Users"},
+		{name: "empty", sql: ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := isDML(tt.sql); got != tt.want {
+				t.Errorf("isDML() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_wireValue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		v       any
+		want    any
+		wantErr bool
+	}{
+		{name: "INT64", v: "42", want: "42"},
+		{name: "FLOAT64", v: 1.5, want: 1.5},
+		{name: "NULL", v: nil, want: spanner.NullString{}},
+		{name: "ARRAY<STRING>", v: []any{"a", nil}, want: []spanner.NullString{{StringVal: "a", Valid: true}, {}}},
+		{name: "ARRAY<FLOAT64>", v: []any{1.5}, want: []spanner.NullFloat64{{Float64: 1.5, Valid: true}}},
+		{name: "ARRAY<BOOL>", v: []any{true}, want: []spanner.NullBool{{Bool: true, Valid: true}}},
+		{name: "mixed array", v: []any{1.5, "Infinity"}, wantErr: true},
+		{name: "struct", v: map[string]any{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := wireValue(tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("wireValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("wireValue() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
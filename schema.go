@@ -0,0 +1,67 @@
+package dbinitiator
+
+import (
+	"context"
+
+	"cloud.google.com/go/spanner"
+	"github.com/go-playground/errors/v5"
+)
+
+// Table describes a table of the database
+type Table struct {
+	Name string
+
+	// ParentTable is the table this table is interleaved in, if any
+	ParentTable string
+
+	Columns []Column
+}
+
+// Column describes a column of a Table
+type Column struct {
+	Name string
+
+	// SpannerType is the column type as declared in DDL, e.g. STRING(MAX) or ARRAY<INT64>
+	SpannerType string
+
+	Nullable  bool
+	Generated bool
+}
+
+// Tables returns the tables of the database ordered by name, with their columns in the order
+// they were defined. Views and INFORMATION_SCHEMA tables are not included.
+func (db *SpannerDB) Tables(ctx context.Context) ([]Table, error) {
+	stmt := spanner.Statement{
+		SQL: `SELECT t.TABLE_NAME, t.PARENT_TABLE_NAME, c.COLUMN_NAME, c.SPANNER_TYPE, c.IS_NULLABLE, c.IS_GENERATED
+			FROM INFORMATION_SCHEMA.TABLES AS t
+			JOIN INFORMATION_SCHEMA.COLUMNS AS c ON c.TABLE_SCHEMA = t.TABLE_SCHEMA AND c.TABLE_NAME = t.TABLE_NAME
+			WHERE t.TABLE_SCHEMA = '' AND t.TABLE_TYPE = 'BASE TABLE'
+			ORDER BY t.TABLE_NAME, c.ORDINAL_POSITION`,
+	}
+
+	var tables []Table
+	if err := db.Single().Query(ctx, stmt).Do(func(r *spanner.Row) error {
+		var table, column, spannerType, nullable, generated string
+		var parent spanner.NullString
+		if err := r.Columns(&table, &parent, &column, &spannerType, &nullable, &generated); err != nil {
+			return errors.Wrap(err, "spanner.Row.Columns()")
+		}
+
+		if len(tables) == 0 || tables[len(tables)-1].Name != table {
+			tables = append(tables, Table{Name: table, ParentTable: parent.StringVal})
+		}
+		t := &tables[len(tables)-1]
+		t.Columns = append(t.Columns, Column{
+			Name:        column,
+			SpannerType: spannerType,
+			Nullable:    nullable == "YES",
+			Generated:   generated != "NEVER",
+		})
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to list tables")
+	}
+
+	return tables, nil
+}
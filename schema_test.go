@@ -0,0 +1,60 @@
+package dbinitiator
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSpannerDB_Tables(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	db, err := container.CreateTestDatabase(ctx, t.Name())
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateTestDatabase() error = %v", err)
+	}
+	db.Cleanup(t)
+
+	if err := db.MigrateUp("file://testdata/generate"); err != nil {
+		t.Fatalf("SpannerDB.MigrateUp() error = %v", err)
+	}
+
+	tables, err := db.Tables(ctx)
+	if err != nil {
+		t.Fatalf("SpannerDB.Tables() error = %v", err)
+	}
+
+	byName := make(map[string]Table)
+	for _, table := range tables {
+		byName[table.Name] = table
+	}
+
+	addresses, ok := byName["Addresses"]
+	if !ok {
+		t.Fatalf("SpannerDB.Tables() = %v, missing Addresses", tables)
+	}
+	if addresses.ParentTable != "Customers" {
+		t.Errorf("Table.ParentTable = %v, want Customers", addresses.ParentTable)
+	}
+
+	want := []Column{
+		{Name: "CustomerId", SpannerType: "INT64"},
+		{Name: "AddressId", SpannerType: "INT64"},
+		{Name: "Street", SpannerType: "STRING(MAX)"},
+		{Name: "ValidFrom", SpannerType: "DATE", Nullable: true},
+	}
+	if len(addresses.Columns) != len(want) {
+		t.Fatalf("Table.Columns = %v, want %v", addresses.Columns, want)
+	}
+	for i := range want {
+		if addresses.Columns[i] != want[i] {
+			t.Errorf("Table.Columns[%d] = %v, want %v", i, addresses.Columns[i], want[i])
+		}
+	}
+}
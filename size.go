@@ -0,0 +1,45 @@
+package dbinitiator
+
+import (
+	"context"
+
+	"cloud.google.com/go/spanner"
+	"github.com/go-playground/errors/v5"
+)
+
+// TableSize is the size of a table reported by SizeReport
+type TableSize struct {
+	Table string
+	Rows  int64
+}
+
+// SizeReport returns the number of rows in each table of the database, ordered by table name,
+// so long running suites can assert they are not growing a shared database unboundedly.
+// The emulator does not expose storage statistics (SPANNER_SYS), so sizes are row counts;
+// there are no dead tuples to report since Spanner has no MVCC bloat to vacuum.
+func (db *SpannerDB) SizeReport(ctx context.Context) ([]TableSize, error) {
+	tables, err := db.Tables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	txn := db.ReadOnlyTransaction()
+	defer txn.Close()
+
+	sizes := make([]TableSize, 0, len(tables))
+	for _, t := range tables {
+		size := TableSize{Table: t.Name}
+		if err := txn.Query(ctx, spanner.Statement{SQL: "SELECT COUNT(*) FROM " + QuoteIdentifier(t.Name)}).Do(func(r *spanner.Row) error {
+			if err := r.Column(0, &size.Rows); err != nil {
+				return errors.Wrap(err, "spanner.Row.Column()")
+			}
+
+			return nil
+		}); err != nil {
+			return nil, errors.Wrapf(err, "failed to count rows of table %s", t.Name)
+		}
+		sizes = append(sizes, size)
+	}
+
+	return sizes, nil
+}
@@ -0,0 +1,54 @@
+package dbinitiator
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+)
+
+func TestSpannerDB_SizeReport(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	db, err := container.CreateTestDatabase(ctx, t.Name())
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateTestDatabase() error = %v", err)
+	}
+	db.Cleanup(t)
+
+	if err := db.MigrateUp("file://testdata/migrations"); err != nil {
+		t.Fatalf("SpannerDB.MigrateUp() error = %v", err)
+	}
+
+	if _, err := db.Apply(ctx, []*spanner.Mutation{
+		spanner.Insert("Users", []string{"Id", "Username"}, []any{"1", "alice"}),
+		spanner.Insert("Users", []string{"Id", "Username"}, []any{"2", "bob"}),
+	}); err != nil {
+		t.Fatalf("spanner.Client.Apply() error = %v", err)
+	}
+
+	sizes, err := db.SizeReport(ctx)
+	if err != nil {
+		t.Fatalf("SpannerDB.SizeReport() error = %v", err)
+	}
+
+	var found bool
+	for _, s := range sizes {
+		if s.Table == "Users" {
+			found = true
+			if s.Rows != 2 {
+				t.Errorf("TableSize.Rows = %d, want 2", s.Rows)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("SpannerDB.SizeReport() = %v, missing Users", sizes)
+	}
+}
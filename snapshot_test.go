@@ -0,0 +1,71 @@
+package dbinitiator
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+)
+
+func TestSpannerDB_SnapshotTables(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	db, err := container.CreateTestDatabase(ctx, t.Name())
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateTestDatabase() error = %v", err)
+	}
+	db.Cleanup(t)
+
+	if err := db.MigrateUp("file://testdata/migrations"); err != nil {
+		t.Fatalf("SpannerDB.MigrateUp() error = %v", err)
+	}
+
+	if err := db.RestoreTables(ctx); err == nil {
+		t.Errorf("SpannerDB.RestoreTables() error = nil, want error without a snapshot")
+	}
+
+	if _, err := db.Apply(ctx, []*spanner.Mutation{
+		spanner.Insert("Users", []string{"Id", "Username"}, []any{"1", "alice"}),
+		spanner.Insert("Users", []string{"Id", "Username"}, []any{"2", "bob"}),
+	}); err != nil {
+		t.Fatalf("spanner.Client.Apply() error = %v", err)
+	}
+
+	dump := func() string {
+		w := &bytes.Buffer{}
+		if err := db.DumpTableJSON(ctx, "Users", w); err != nil {
+			t.Fatalf("SpannerDB.DumpTableJSON() error = %v", err)
+		}
+
+		return w.String()
+	}
+	want := dump()
+
+	if err := db.SnapshotTables(ctx, "Users"); err != nil {
+		t.Fatalf("SpannerDB.SnapshotTables() error = %v", err)
+	}
+
+	if _, err := db.Apply(ctx, []*spanner.Mutation{
+		spanner.Delete("Users", spanner.Key{"1"}),
+		spanner.Update("Users", []string{"Id", "Username"}, []any{"2", "robert"}),
+		spanner.Insert("Users", []string{"Id", "Username"}, []any{"3", "carol"}),
+	}); err != nil {
+		t.Fatalf("spanner.Client.Apply() error = %v", err)
+	}
+
+	if err := db.RestoreTables(ctx); err != nil {
+		t.Fatalf("SpannerDB.RestoreTables() error = %v", err)
+	}
+
+	if got := dump(); got != want {
+		t.Errorf("SpannerDB.RestoreTables() table = %v, want %v", got, want)
+	}
+}
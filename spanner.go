@@ -5,21 +5,31 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"log/slog"
+	"net"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"cloud.google.com/go/spanner"
 	database "cloud.google.com/go/spanner/admin/database/apiv1"
 	"github.com/docker/go-connections/nat"
 	"github.com/go-playground/errors/v5"
 	_ "github.com/golang-migrate/migrate/v4/database/spanner" // spanner driver for the migrate package
 	_ "github.com/golang-migrate/migrate/v4/source/file"      // up/down script file source driver for the migrate package
 	"github.com/testcontainers/testcontainers-go"
-	"github.com/testcontainers/testcontainers-go/wait"
+	"github.com/testcontainers/testcontainers-go/network"
 	"google.golang.org/api/option"
 	"google.golang.org/api/option/internaloption"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
+// testDatabasePrefix marks the databases created by CreateTestDatabase
+const testDatabasePrefix = "t-"
+
 const (
 	defaultSpannerPort       = "9010/tcp"
 	defaultSpannerProjectID  = "unit-testing"
@@ -29,82 +39,342 @@ const (
 // SpannerContainer represents a docker container running a spanner instance.
 type SpannerContainer struct {
 	testcontainers.Container
-	admin      *database.DatabaseAdminClient
-	opts       []option.ClientOption
-	port       string
-	projectID  string
-	instanceID string
+	admin          *database.DatabaseAdminClient
+	opts           []option.ClientOption
+	port           string
+	endpoint       string
+	projectID      string
+	instanceID     string
+	keep           bool
+	kept           atomic.Bool
+	proxy          *Toxiproxy
+	network        *testcontainers.DockerNetwork
+	logger         *slog.Logger
+	debug          bool
+	timings        *Timings
+	createSem      chan struct{}
+	pool           spanner.SessionPoolConfig
+	migrations     []string
+	migrationsGlob string
 
 	mu      sync.Mutex
 	dbCount int
 }
 
 // NewSpannerContainer returns a initialized SpannerContainer ready to run to create databases for unit tests
-func NewSpannerContainer(ctx context.Context, imageVersion string) (*SpannerContainer, error) {
-	container, err := testcontainers.GenericContainer(ctx,
-		testcontainers.GenericContainerRequest{
-			Started: true,
-			ContainerRequest: testcontainers.ContainerRequest{
-				Image:        "gcr.io/cloud-spanner-emulator/emulator:" + imageVersion,
-				WaitingFor:   wait.ForLog("Cloud Spanner emulator running"),
-				ExposedPorts: []string{defaultSpannerPort},
-			},
-		},
-	)
-	if err != nil {
-		return nil, errors.Wrap(err, "testcontainers.GenericContainer()")
+func NewSpannerContainer(ctx context.Context, imageVersion string, opts ...Option) (*SpannerContainer, error) {
+	return NewSpannerContainerFromConfig(ctx, &Config{Image: imageReference(imageVersion)}, opts...)
+}
+
+// NewSpannerContainerFromConfig returns a initialized SpannerContainer configured by cfg. Use
+// ConfigFromEnv to configure the container from the environment.
+func NewSpannerContainerFromConfig(ctx context.Context, cfg *Config, opts ...Option) (_ *SpannerContainer, err error) {
+	cfg = cfg.withDefaults(opts...)
+	if err := cfg.validate(); err != nil {
+		return nil, err
 	}
+	cfg.Logger = cfg.logger()
 
-	host, err := container.Host(ctx)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get host for container")
+	if err := checkDocker(ctx); err != nil {
+		return nil, err
 	}
 
-	externalPort, err := container.MappedPort(ctx, nat.Port(defaultSpannerPort))
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to get external port for exposed port %s", defaultSpannerPort)
+	sp := &SpannerContainer{
+		port:           defaultSpannerPort,
+		projectID:      cfg.ProjectID,
+		instanceID:     cfg.InstanceID,
+		keep:           cfg.Keep,
+		logger:         cfg.Logger,
+		debug:          cfg.Debug,
+		timings:        newTimings(),
+		createSem:      make(chan struct{}, cfg.maxConcurrentCreates()),
+		pool:           cfg.sessionPoolConfig(),
+		migrations:     cfg.Migrations,
+		migrationsGlob: cfg.MigrationsGlob,
 	}
+	defer func() {
+		if err != nil {
+			sp.abort(ctx, cfg.Reuse)
+		}
+	}()
+
+	if sp.network, err = proxyNetwork(ctx, cfg); err != nil {
+		return nil, err
+	}
+
+	cfg.Logger.Info("starting spanner emulator container", "image", cfg.Image, "reuse", cfg.Reuse)
+	start := time.Now()
 
-	endPoint := fmt.Sprintf("%s:%s", host, externalPort.Port())
+	if err := sp.startContainer(ctx, cfg); err != nil {
+		return nil, err
+	}
+
+	cfg.Logger.Info("spanner emulator started", "container_id", sp.GetContainerID(), "endpoint", sp.endpoint)
+	if cfg.Debug {
+		logContainerDiagnostics(ctx, cfg.Logger, sp.Container)
+	}
 
-	opts := []option.ClientOption{
-		option.WithEndpoint(endPoint),
+	sp.opts = []option.ClientOption{
+		option.WithEndpoint(sp.endpoint),
 		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
 		option.WithoutAuthentication(),
 		internaloption.SkipDialSettingsValidation(),
 	}
 
-	if err := NewSpannerInstance(ctx, defaultSpannerProjectID, defaultSpannerInstanceID, opts...); err != nil {
-		return nil, errors.Wrap(err, "failed to create spanner instance")
+	if err := createInstance(ctx, cfg, sp.opts); err != nil {
+		return nil, err
 	}
 
-	admin, err := database.NewDatabaseAdminClient(ctx, opts...)
-	if err != nil {
+	if sp.admin, err = database.NewDatabaseAdminClient(ctx, sp.opts...); err != nil {
 		return nil, errors.Wrap(err, "database.NewDatabaseAdminClient()")
 	}
+	sp.timings.record(StepContainerStart, start)
+
+	if cfg.StaleDatabaseTTL > 0 {
+		if _, err := sp.DropStaleDatabases(ctx, cfg.StaleDatabaseTTL); err != nil {
+			return nil, err
+		}
+	}
+
+	return sp, nil
+}
+
+// startContainer starts the emulator container, and its toxiproxy sidecar when enabled
+func (sp *SpannerContainer) startContainer(ctx context.Context, cfg *Config) error {
+	req, err := cfg.containerRequest(ctx)
+	if err != nil {
+		return err
+	}
+
+	// On failure the container may still have been created, so keep it for abort
+	if sp.Container, err = testcontainers.GenericContainer(ctx, req); err != nil {
+		return errors.Wrap(withSentinel(ErrContainerStartFailed, err), "testcontainers.GenericContainer()")
+	}
+
+	if sp.endpoint, err = containerEndpoint(ctx, sp.Container); err != nil {
+		return err
+	}
+
+	if cfg.Toxiproxy {
+		if sp.proxy, err = startSpannerProxy(ctx, sp.Container, cfg.Network); err != nil {
+			return err
+		}
+		sp.endpoint = sp.proxy.endpoint
+		cfg.Logger.Info("started toxiproxy sidecar", "container_id", sp.proxy.GetContainerID(), "endpoint", sp.endpoint)
+	}
+
+	return nil
+}
+
+// abort releases the resources of a container that failed to start: the admin client, the
+// toxiproxy sidecar, the container and the proxy network. A reused container is left running
+// for the other runs sharing it.
+func (sp *SpannerContainer) abort(ctx context.Context, reuse bool) {
+	if sp.admin != nil {
+		_ = sp.admin.Close()
+	}
+	if sp.proxy != nil {
+		_ = sp.proxy.Terminate(ctx)
+	}
+	if sp.Container != nil && !reuse {
+		_ = sp.Container.Terminate(ctx)
+	}
+	if sp.network != nil {
+		_ = sp.network.Remove(ctx)
+	}
+}
+
+// proxyNetwork creates the network connecting the emulator to its toxiproxy sidecar, when
+// toxiproxy is enabled without a configured network
+func proxyNetwork(ctx context.Context, cfg *Config) (*testcontainers.DockerNetwork, error) {
+	if !cfg.Toxiproxy || cfg.Network != "" {
+		return nil, nil
+	}
+
+	nw, err := network.New(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "network.New()")
+	}
+	cfg.Network = nw.Name
 
-	return &SpannerContainer{
-		Container:  container,
-		admin:      admin,
-		opts:       opts,
-		port:       defaultSpannerPort,
-		projectID:  defaultSpannerProjectID,
-		instanceID: defaultSpannerInstanceID,
-	}, nil
+	return nw, nil
 }
 
-// CreateTestDatabase creates a database with dbName. Each test should create their own database for testing
+// createInstance creates the emulator instance databases are created in
+func createInstance(ctx context.Context, cfg *Config, opts []option.ClientOption) error {
+	if err := NewSpannerInstance(ctx, cfg.ProjectID, cfg.InstanceID, opts...); err != nil {
+		// A reused container already has the instance from the run that started it
+		if !cfg.Reuse || status.Code(err) != codes.AlreadyExists {
+			return errors.Wrap(err, "failed to create spanner instance")
+		}
+	}
+	cfg.Logger.Info("spanner instance ready", "project_id", cfg.ProjectID, "instance_id", cfg.InstanceID)
+
+	return nil
+}
+
+// containerEndpoint returns the host:port the emulator port of container is mapped to
+func containerEndpoint(ctx context.Context, container testcontainers.Container) (string, error) {
+	host, err := container.Host(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get host for container")
+	}
+
+	externalPort, err := container.MappedPort(ctx, nat.Port(defaultSpannerPort))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get external port for exposed port %s", defaultSpannerPort)
+	}
+
+	return fmt.Sprintf("%s:%s", host, externalPort.Port()), nil
+}
+
+// startSpannerProxy starts a toxiproxy sidecar on networkName in front of the emulator container
+func startSpannerProxy(ctx context.Context, container testcontainers.Container, networkName string) (*Toxiproxy, error) {
+	ip, err := container.ContainerIP(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get container ip")
+	}
+
+	proxy, err := startToxiproxy(ctx, defaultToxiproxyImage, networkName, net.JoinHostPort(ip, nat.Port(defaultSpannerPort).Port()))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start toxiproxy")
+	}
+
+	return proxy, nil
+}
+
+// CreateTestDatabase creates a database with dbName. Each test should create their own database for testing.
+// The name is prefixed with t- to mark it as a test database, which DropStaleDatabases may drop,
+// so a test creating "users" gets the database "t-users" (see SpannerDB.DatabaseName). Use
+// CreateDatabase for a database with exactly dbName.
 func (sp *SpannerContainer) CreateTestDatabase(ctx context.Context, dbName string) (*SpannerDB, error) {
-	dbName = sp.validDatabaseName(dbName)
+	return sp.createDatabase(ctx, sp.validDatabaseName(testDatabasePrefix, dbName))
+}
+
+// CreateDatabase creates a database with dbName that is not a test database, e.g. for local
+// development. It is never dropped by DropStaleDatabases.
+func (sp *SpannerContainer) CreateDatabase(ctx context.Context, dbName string) (*SpannerDB, error) {
+	return sp.createDatabase(ctx, sp.validDatabaseName("", dbName))
+}
 
-	db, err := newSpannerDatabase(ctx, sp.admin, sp.projectID, sp.instanceID, dbName, sp.opts...)
+func (sp *SpannerContainer) createDatabase(ctx context.Context, dbName string) (*SpannerDB, error) {
+	select {
+	case sp.createSem <- struct{}{}:
+		defer func() { <-sp.createSem }()
+	case <-ctx.Done():
+		return nil, errors.Wrapf(ctx.Err(), "failed waiting to create spanner database %s", dbName)
+	}
+
+	start := time.Now()
+	db, err := newSpannerDatabase(ctx, sp.admin, sp.logger, sp.debug, sp.pool, sp.projectID, sp.instanceID, dbName, sp.opts...)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to create spanner database %s", dbName)
 	}
+	db.container = sp
+	db.timings = sp.timings
+	sp.timings.record(StepCreateDatabase, start)
+	sp.logger.Info("created database", "database", db.dbStr)
 
 	return db, nil
 }
 
+// Endpoint returns the host:port the emulator is reachable on, suitable for SPANNER_EMULATOR_HOST
+func (sp *SpannerContainer) Endpoint() string {
+	return sp.endpoint
+}
+
+// Terminate stops and removes the container, unless it holds databases kept for failed tests.
+func (sp *SpannerContainer) Terminate(ctx context.Context) error {
+	if sp.kept.Load() {
+		sp.logger.Warn("keeping container with databases of failed tests", "container_id", sp.GetContainerID(), "endpoint", sp.endpoint)
+
+		return nil
+	}
+
+	if sp.proxy != nil {
+		if err := sp.proxy.Terminate(ctx); err != nil {
+			return errors.Wrap(err, "testcontainers.Container.Terminate(): toxiproxy")
+		}
+	}
+
+	if err := sp.Container.Terminate(ctx); err != nil {
+		return errors.Wrap(err, "testcontainers.Container.Terminate()")
+	}
+
+	if sp.network != nil {
+		if err := sp.network.Remove(ctx); err != nil {
+			return errors.Wrap(err, "testcontainers.DockerNetwork.Remove()")
+		}
+	}
+	sp.logger.Info("spanner emulator terminated", "container_id", sp.GetContainerID(), "timings", sp.timings.Summary())
+
+	return nil
+}
+
+// Proxy returns the toxiproxy sidecar used to inject network faults, or nil if the container
+// was not started with WithToxiproxy.
+func (sp *SpannerContainer) Proxy() *Toxiproxy {
+	return sp.proxy
+}
+
+// Pause suspends all processes in the container (docker pause), simulating a database outage.
+// Clients see requests hang rather than fail until Unpause is called.
+func (sp *SpannerContainer) Pause(ctx context.Context) error {
+	cli, err := testcontainers.NewDockerClientWithOpts(ctx)
+	if err != nil {
+		return errors.Wrap(err, "testcontainers.NewDockerClientWithOpts()")
+	}
+	defer cli.Close()
+
+	if err := cli.ContainerPause(ctx, sp.GetContainerID()); err != nil {
+		return errors.Wrap(err, "client.Client.ContainerPause()")
+	}
+
+	return nil
+}
+
+// Unpause resumes a container suspended by Pause
+func (sp *SpannerContainer) Unpause(ctx context.Context) error {
+	cli, err := testcontainers.NewDockerClientWithOpts(ctx)
+	if err != nil {
+		return errors.Wrap(err, "testcontainers.NewDockerClientWithOpts()")
+	}
+	defer cli.Close()
+
+	if err := cli.ContainerUnpause(ctx, sp.GetContainerID()); err != nil {
+		return errors.Wrap(err, "client.Client.ContainerUnpause()")
+	}
+
+	return nil
+}
+
+// ContainerExists reports whether the container named by cfg.ContainerName exists, running or
+// stopped, so tools can act on a reused container without starting one. Without a name, the
+// default name of reused containers is used.
+func ContainerExists(ctx context.Context, cfg *Config) (bool, error) {
+	name := cfg.ContainerName
+	if name == "" {
+		name = defaultContainerName
+	}
+
+	cli, err := testcontainers.NewDockerClientWithOpts(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "testcontainers.NewDockerClientWithOpts()")
+	}
+	defer cli.Close()
+
+	if _, err := cli.ContainerInspect(ctx, name); err != nil {
+		var notFound interface{ NotFound() }
+		if errors.As(err, &notFound) {
+			return false, nil
+		}
+
+		return false, errors.Wrap(err, "client.Client.ContainerInspect()")
+	}
+
+	return true, nil
+}
+
 // Close cleans up open resouces
 func (sp *SpannerContainer) Close() error {
 	if err := sp.admin.Close(); err != nil {
@@ -114,7 +384,9 @@ func (sp *SpannerContainer) Close() error {
 	return nil
 }
 
-func (sp *SpannerContainer) validDatabaseName(dbName string) string {
+// validDatabaseName returns prefix followed by dbName with invalid characters replaced, shortened
+// to fit the 30 character limit of database IDs
+func (sp *SpannerContainer) validDatabaseName(prefix, dbName string) string {
 	b := []byte(dbName)
 	b = bytes.ToLower(b)
 
@@ -127,12 +399,12 @@ func (sp *SpannerContainer) validDatabaseName(dbName string) string {
 	b = bytes.Trim(b, "-_")
 	dbName = string(b)
 
-	if l := len(dbName); l > 30 {
+	if l := len(dbName); l > 30-len(prefix) {
 		sp.mu.Lock()
 		defer sp.mu.Unlock()
 		sp.dbCount++
 		dbName = fmt.Sprintf("db%d-%s", sp.dbCount, dbName[l-20:])
 	}
 
-	return dbName
+	return prefix + dbName
 }
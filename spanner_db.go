@@ -3,6 +3,11 @@ package dbinitiator
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"cloud.google.com/go/spanner"
 	database "cloud.google.com/go/spanner/admin/database/apiv1"
@@ -19,8 +24,19 @@ import (
 // SpannerDB represents a database created and ready for migrations
 type SpannerDB struct {
 	dbStr      string
+	opts       []option.ClientOption
 	admin      *database.DatabaseAdminClient
 	closeAdmin bool
+	container  *SpannerContainer
+	logger     *slog.Logger
+	debug      bool
+	timings    *Timings
+	queries    *atomic.Int64
+
+	mu       sync.Mutex
+	snapshot []tableSnapshot
+	locks    map[int64]chan struct{}
+
 	*spanner.Client
 }
 
@@ -31,7 +47,7 @@ func NewSpannerDatabase(ctx context.Context, projectID, instanceID, dbName strin
 		return nil, errors.Wrap(err, "database.NewDatabaseAdminClient()")
 	}
 
-	db, err := newSpannerDatabase(ctx, adminClient, projectID, instanceID, dbName, opts...)
+	db, err := newSpannerDatabase(ctx, adminClient, discardLogger(), false, spanner.DefaultSessionPoolConfig, projectID, instanceID, dbName, opts...)
 	if err != nil {
 		adminClient.Close()
 
@@ -43,41 +59,77 @@ func NewSpannerDatabase(ctx context.Context, projectID, instanceID, dbName strin
 	return db, nil
 }
 
-func newSpannerDatabase(ctx context.Context, adminClient *database.DatabaseAdminClient, projectID, instanceID, dbName string, opts ...option.ClientOption) (*SpannerDB, error) {
+func newSpannerDatabase(
+	ctx context.Context, adminClient *database.DatabaseAdminClient, logger *slog.Logger, debug bool, pool spanner.SessionPoolConfig,
+	projectID, instanceID, dbName string, opts ...option.ClientOption,
+) (*SpannerDB, error) {
 	dbStr := fmt.Sprintf("projects/%s/instances/%s/databases/%s", projectID, instanceID, dbName)
-	client, err := spanner.NewClient(ctx, dbStr, opts...)
-	if err != nil {
-		return nil, errors.Wrapf(err, "spanner.NewClient()")
-	}
 
+	stmt := "CREATE DATABASE " + QuoteIdentifier(dbName)
+	if debug {
+		logger.Debug("executing DDL", "database", dbStr, "statement", stmt)
+	}
 	op, err := adminClient.CreateDatabase(ctx,
 		&databasepb.CreateDatabaseRequest{
 			Parent:          fmt.Sprintf("projects/%s/instances/%s", projectID, instanceID),
-			CreateStatement: fmt.Sprintf("CREATE DATABASE `%s`", dbName),
+			CreateStatement: stmt,
 		},
 	)
 	if err != nil {
-		return nil, errors.Wrapf(err, "database.DatabaseAdminClient.CreateDatabase()")
+		return nil, errors.Wrapf(createDatabaseError(err), "database.DatabaseAdminClient.CreateDatabase()")
 	}
 
 	if _, err := op.Wait(ctx); err != nil {
-		return nil, errors.Wrapf(err, "database.CreateDatabaseOperation.Wait()")
+		return nil, errors.Wrapf(createDatabaseError(err), "database.CreateDatabaseOperation.Wait()")
+	}
+
+	// The client is created once the database exists so it is not leaked when creation fails.
+	// It counts its statements for WithQueryBudget.
+	queries := new(atomic.Int64)
+	clientOpts := append(slices.Clip(opts), countingClientOptions(queries)...)
+	client, err := spanner.NewClientWithConfig(ctx, dbStr, spanner.ClientConfig{SessionPoolConfig: pool}, clientOpts...)
+	if err != nil {
+		// Drop the database so it does not count toward the emulator limit, even if ctx is done
+		if dropErr := adminClient.DropDatabase(context.WithoutCancel(ctx), &databasepb.DropDatabaseRequest{Database: dbStr}); dropErr != nil {
+			logger.Warn("failed to drop database after client creation failed", "database", dbStr, "error", dropErr)
+		}
+
+		return nil, errors.Wrapf(err, "spanner.NewClientWithConfig()")
 	}
 
 	return &SpannerDB{
-		dbStr:  dbStr,
-		admin:  adminClient,
-		Client: client,
+		dbStr:   dbStr,
+		opts:    opts,
+		admin:   adminClient,
+		logger:  logger,
+		debug:   debug,
+		queries: queries,
+		Client:  client,
 	}, nil
 }
 
-// MigrateUp will migrate all the way up, applying all up migrations from all sourceURL's
+// DatabaseName returns the fully qualified database name (projects/.../instances/.../databases/...)
+func (db *SpannerDB) DatabaseName() string {
+	return db.dbStr
+}
+
+// MigrateUp will migrate all the way up, applying all up migrations from all sourceURL's.
+// Without any sourceURL, the migrations configured on the container are applied (see
+// WithMigrations and WithMigrationDiscovery).
 func (db *SpannerDB) MigrateUp(sourceURL ...string) error {
-	conf := &spannerDriver.Config{DatabaseName: db.dbStr, CleanStatements: true, DoNotCloseSpannerClients: true}
-	spannerInstance, err := spannerDriver.WithInstance(spannerDriver.NewDB(*db.admin, *db.Client), conf)
+	if len(sourceURL) == 0 && db.container != nil {
+		sources, err := db.container.defaultMigrations()
+		if err != nil {
+			return err
+		}
+		sourceURL = sources
+	}
+
+	spannerInstance, closeClient, err := db.migrationDriver()
 	if err != nil {
-		return errors.Wrap(err, "spannerDriver.WithInstance()")
+		return err
 	}
+	defer closeClient()
 
 	for _, source := range sourceURL {
 		if err := db.migrateUp(source, spannerInstance); err != nil {
@@ -88,12 +140,41 @@ func (db *SpannerDB) MigrateUp(sourceURL ...string) error {
 	return nil
 }
 
+// migrationDriver returns the migrate driver for the database. Migrations run on their own
+// client, so their statements do not count toward WithQueryBudget. The returned func closes
+// the client.
+func (db *SpannerDB) migrationDriver() (migratedb.Driver, func(), error) {
+	// Sessions are created as the migrations need them
+	pool := spanner.DefaultSessionPoolConfig
+	pool.MinOpened = 0
+	client, err := spanner.NewClientWithConfig(context.Background(), db.dbStr, spanner.ClientConfig{SessionPoolConfig: pool}, db.opts...)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "spanner.NewClientWithConfig()")
+	}
+
+	conf := &spannerDriver.Config{DatabaseName: db.dbStr, CleanStatements: true, DoNotCloseSpannerClients: true}
+	spannerInstance, err := spannerDriver.WithInstance(spannerDriver.NewDB(*db.admin, *client), conf)
+	if err != nil {
+		client.Close()
+
+		return nil, nil, errors.Wrap(err, "spannerDriver.WithInstance()")
+	}
+
+	return spannerInstance, client.Close, nil
+}
+
 func (db *SpannerDB) migrateUp(source string, spannerInstance migratedb.Driver) error {
+	db.logger.Info("migrating up", "database", db.dbStr, "source", source)
+	start := time.Now()
+
 	m, err := migrate.NewWithDatabaseInstance(source, "spanner", spannerInstance)
 	if err != nil {
 		return errors.Wrapf(err, "migrate.NewWithDatabaseInstance(): fileURL=%s, db=%s", source, db.dbStr)
 	}
 	defer m.Close()
+	if db.debug {
+		m.Log = printfLogger{logger: db.logger}
+	}
 
 	if _, _, err := m.Version(); !errors.Is(err, migrate.ErrNilVersion) {
 		if err := m.Force(-1); err != nil {
@@ -102,7 +183,7 @@ func (db *SpannerDB) migrateUp(source string, spannerInstance migratedb.Driver)
 	}
 
 	if err := m.Up(); err != nil {
-		return errors.Wrapf(err, "migrate.Migrate.Up(): %s", source)
+		return errors.Wrapf(withSentinel(ErrMigrationFailed, err), "migrate.Migrate.Up(): %s", source)
 	}
 
 	if err, dbErr := m.Close(); err != nil {
@@ -110,26 +191,34 @@ func (db *SpannerDB) migrateUp(source string, spannerInstance migratedb.Driver)
 	} else if dbErr != nil {
 		return errors.Wrapf(dbErr, "migrate.Migrate.Close(): database error: %s", source)
 	}
+	db.timings.record(StepMigrateUp, start)
+	db.logger.Info("migrated up", "database", db.dbStr, "source", source)
 
 	return nil
 }
 
 // MigrateDown will migrate all the way down
 func (db *SpannerDB) MigrateDown(sourceURL string) error {
-	conf := &spannerDriver.Config{DatabaseName: db.dbStr, CleanStatements: true, DoNotCloseSpannerClients: true}
-	spannerInstance, err := spannerDriver.WithInstance(spannerDriver.NewDB(*db.admin, *db.Client), conf)
+	db.logger.Info("migrating down", "database", db.dbStr, "source", sourceURL)
+	start := time.Now()
+
+	spannerInstance, closeClient, err := db.migrationDriver()
 	if err != nil {
-		return errors.Wrap(err, "spannerDriver.WithInstance()")
+		return err
 	}
+	defer closeClient()
 
 	m, err := migrate.NewWithDatabaseInstance(sourceURL, "spanner", spannerInstance)
 	if err != nil {
 		return errors.Wrapf(err, "migrate.NewWithDatabaseInstance(): fileURL=%s, db=%s", sourceURL, db.dbStr)
 	}
 	defer m.Close()
+	if db.debug {
+		m.Log = printfLogger{logger: db.logger}
+	}
 
 	if err := m.Down(); err != nil {
-		return errors.Wrap(err, "migrate.Migrate.Down()")
+		return errors.Wrap(withSentinel(ErrMigrationFailed, err), "migrate.Migrate.Down()")
 	}
 
 	if err, dbErr := m.Close(); err != nil {
@@ -137,20 +226,49 @@ func (db *SpannerDB) MigrateDown(sourceURL string) error {
 	} else if dbErr != nil {
 		return errors.Wrap(dbErr, "migrate.Migrate.Close(): database error")
 	}
+	db.timings.record(StepMigrateDown, start)
+	db.logger.Info("migrated down", "database", db.dbStr, "source", sourceURL)
+
+	return nil
+}
+
+// Analyze updates the query optimizer statistics for the database, so query plans are based
+// on the seeded data rather than empty-table estimates. Spanner analyzes the whole database;
+// it has no per-table statistics.
+func (db *SpannerDB) Analyze(ctx context.Context) error {
+	if db.debug {
+		db.logger.Debug("executing DDL", "database", db.dbStr, "statement", "ANALYZE")
+	}
+	op, err := db.admin.UpdateDatabaseDdl(ctx, &databasepb.UpdateDatabaseDdlRequest{
+		Database:   db.dbStr,
+		Statements: []string{"ANALYZE"},
+	})
+	if err != nil {
+		return errors.Wrap(err, "database.DatabaseAdminClient.UpdateDatabaseDdl()")
+	}
+
+	if err := op.Wait(ctx); err != nil {
+		return errors.Wrap(err, "database.UpdateDatabaseDdlOperation.Wait()")
+	}
 
 	return nil
 }
 
 func (db *SpannerDB) DropDatabase(ctx context.Context) error {
+	if db.debug {
+		db.logger.Debug("executing DDL", "database", db.dbStr, "statement", "DROP DATABASE")
+	}
 	if err := db.admin.DropDatabase(ctx, &databasepb.DropDatabaseRequest{Database: db.dbStr}); err != nil {
 		return errors.Wrap(err, "database.DatabaseAdminClient.DropDatabase()")
 	}
+	db.logger.Info("dropped database", "database", db.dbStr)
 
 	return nil
 }
 
 func (db *SpannerDB) Close() error {
 	db.Client.Close()
+	db.logger.Debug("closed database client", "database", db.dbStr)
 
 	if db.closeAdmin {
 		if err := db.admin.Close(); err != nil {
@@ -0,0 +1,151 @@
+package dbinitiator
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/go-playground/errors/v5"
+)
+
+// DumpTableJSON writes all rows of table to w as a JSON array ordered by primary key. The
+// output is stable across runs, making it suitable for golden-file comparisons.
+func (db *SpannerDB) DumpTableJSON(ctx context.Context, table string, w io.Writer) error {
+	columns, err := db.tableColumns(ctx, table)
+	if err != nil {
+		return err
+	}
+
+	rows, err := rowsToJSON(db.Single().Read(ctx, table, spanner.AllKeys(), columns))
+	if err != nil {
+		return errors.Wrapf(err, "failed to read table %s", table)
+	}
+
+	b, err := marshalJSON(rows)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(b); err != nil {
+		return errors.Wrap(err, "io.Writer.Write()")
+	}
+
+	return nil
+}
+
+// QueryJSON runs stmt and returns the result rows as a JSON array. INT64 and NUMERIC values
+// are emitted as JSON numbers, all other values use their Spanner JSON representation.
+func (db *SpannerDB) QueryJSON(ctx context.Context, stmt spanner.Statement) ([]byte, error) {
+	rows, err := rowsToJSON(db.Single().Query(ctx, stmt))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to run query: %s", stmt.SQL)
+	}
+
+	return marshalJSON(rows)
+}
+
+// tableColumns returns the column names of table in the order they were defined
+func (db *SpannerDB) tableColumns(ctx context.Context, table string) ([]string, error) {
+	stmt := spanner.Statement{
+		SQL: `SELECT COLUMN_NAME FROM INFORMATION_SCHEMA.COLUMNS
+			WHERE TABLE_SCHEMA = '' AND TABLE_NAME = @table
+			ORDER BY ORDINAL_POSITION`,
+		Params: map[string]any{"table": table},
+	}
+
+	return db.queryColumns(ctx, table, stmt)
+}
+
+// queryColumns runs stmt, which selects column names of table, returning an error if the
+// table has no columns
+func (db *SpannerDB) queryColumns(ctx context.Context, table string, stmt spanner.Statement) ([]string, error) {
+	var columns []string
+	if err := db.Single().Query(ctx, stmt).Do(func(r *spanner.Row) error {
+		var column string
+		if err := r.Column(0, &column); err != nil {
+			return errors.Wrap(err, "spanner.Row.Column()")
+		}
+		columns = append(columns, column)
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to list columns for table %s", table)
+	}
+
+	if len(columns) == 0 {
+		return nil, errors.Newf("table %s not found", table)
+	}
+
+	return columns, nil
+}
+
+func rowsToJSON(iter *spanner.RowIterator) ([]map[string]any, error) {
+	rows := make([]map[string]any, 0)
+	if err := iter.Do(func(r *spanner.Row) error {
+		row := make(map[string]any, r.Size())
+		for i, name := range r.ColumnNames() {
+			var col spanner.GenericColumnValue
+			if err := r.Column(i, &col); err != nil {
+				return errors.Wrapf(err, "spanner.Row.Column(): column %s", name)
+			}
+			row[name] = normalizeJSONValue(col.Type, col.Value.AsInterface())
+		}
+		rows = append(rows, row)
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "spanner.RowIterator.Do()")
+	}
+
+	return rows, nil
+}
+
+// normalizeJSONValue converts a value decoded from the Spanner wire format into its
+// JSON representation, based on the column type.
+func normalizeJSONValue(t *spannerpb.Type, v any) any {
+	if v == nil {
+		return nil
+	}
+
+	switch t.GetCode() {
+	case spannerpb.TypeCode_INT64, spannerpb.TypeCode_NUMERIC:
+		if s, ok := v.(string); ok {
+			return json.Number(s)
+		}
+	case spannerpb.TypeCode_ARRAY:
+		if list, ok := v.([]any); ok {
+			out := make([]any, len(list))
+			for i := range list {
+				out[i] = normalizeJSONValue(t.GetArrayElementType(), list[i])
+			}
+
+			return out
+		}
+	case spannerpb.TypeCode_STRUCT:
+		if list, ok := v.([]any); ok {
+			fields := t.GetStructType().GetFields()
+			out := make(map[string]any, len(list))
+			for i := range list {
+				if i < len(fields) {
+					out[fields[i].GetName()] = normalizeJSONValue(fields[i].GetType(), list[i])
+				}
+			}
+
+			return out
+		}
+	default:
+	}
+
+	return v
+}
+
+func marshalJSON(rows []map[string]any) ([]byte, error) {
+	b, err := json.MarshalIndent(rows, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "json.MarshalIndent()")
+	}
+
+	return append(b, '\n'), nil
+}
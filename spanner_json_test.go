@@ -0,0 +1,152 @@
+package dbinitiator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/apiv1/spannerpb"
+)
+
+func TestSpannerDB_DumpTableJSON(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	db, err := container.CreateTestDatabase(ctx, t.Name())
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateTestDatabase() error = %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	if err := db.MigrateUp("file://testdata/migrations"); err != nil {
+		t.Fatalf("SpannerDB.MigrateUp() error = %v", err)
+	}
+
+	if _, err := db.Apply(ctx, []*spanner.Mutation{
+		spanner.Insert("Users", []string{"Id", "Username"}, []any{"2", "bob"}),
+		spanner.Insert("Users", []string{"Id", "Username"}, []any{"1", "alice"}),
+	}); err != nil {
+		t.Fatalf("spanner.Client.Apply() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		table   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "rows ordered by primary key",
+			table: "Users",
+			want: `[
+  {
+    "Email": null,
+    "Firstname": null,
+    "Id": "1",
+    "Lastname": null,
+    "PasswordHash": null,
+    "Username": "alice"
+  },
+  {
+    "Email": null,
+    "Firstname": null,
+    "Id": "2",
+    "Lastname": null,
+    "PasswordHash": null,
+    "Username": "bob"
+  }
+]
+`,
+		},
+		{
+			name:    "table does not exist",
+			table:   "DoesNotExist",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := &bytes.Buffer{}
+			if err := db.DumpTableJSON(ctx, tt.table, w); (err != nil) != tt.wantErr {
+				t.Fatalf("SpannerDB.DumpTableJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := w.String(); got != tt.want {
+				t.Errorf("SpannerDB.DumpTableJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_normalizeJSONValue(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		t *spannerpb.Type
+		v any
+	}
+	tests := []struct {
+		name string
+		args args
+		want any
+	}{
+		{
+			name: "null",
+			args: args{t: &spannerpb.Type{Code: spannerpb.TypeCode_INT64}, v: nil},
+			want: nil,
+		},
+		{
+			name: "int64 becomes number",
+			args: args{t: &spannerpb.Type{Code: spannerpb.TypeCode_INT64}, v: "42"},
+			want: json.Number("42"),
+		},
+		{
+			name: "string is unchanged",
+			args: args{t: &spannerpb.Type{Code: spannerpb.TypeCode_STRING}, v: "42"},
+			want: "42",
+		},
+		{
+			name: "array of int64",
+			args: args{
+				t: &spannerpb.Type{Code: spannerpb.TypeCode_ARRAY, ArrayElementType: &spannerpb.Type{Code: spannerpb.TypeCode_INT64}},
+				v: []any{"1", nil},
+			},
+			want: []any{json.Number("1"), nil},
+		},
+		{
+			name: "struct becomes object",
+			args: args{
+				t: &spannerpb.Type{Code: spannerpb.TypeCode_STRUCT, StructType: &spannerpb.StructType{
+					Fields: []*spannerpb.StructType_Field{
+						{Name: "Id", Type: &spannerpb.Type{Code: spannerpb.TypeCode_INT64}},
+						{Name: "Name", Type: &spannerpb.Type{Code: spannerpb.TypeCode_STRING}},
+					},
+				}},
+				v: []any{"7", "seven"},
+			},
+			want: map[string]any{"Id": json.Number("7"), "Name": "seven"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := normalizeJSONValue(tt.args.t, tt.args.v); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeJSONValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,11 +3,25 @@ package dbinitiator
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"cloud.google.com/go/spanner"
+	database "cloud.google.com/go/spanner/admin/database/apiv1"
 	"github.com/docker/go-connections/nat"
 	_ "github.com/golang-migrate/migrate/v4/database/spanner"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/testcontainers/testcontainers-go"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
 )
 
 func TestClient_FullMigration(t *testing.T) {
@@ -176,6 +190,7 @@ func TestContainer_validDatabaseName(t *testing.T) {
 	t.Parallel()
 
 	type args struct {
+		prefix string
 		dbName string
 	}
 	tests := []struct {
@@ -213,15 +228,250 @@ func TestContainer_validDatabaseName(t *testing.T) {
 			args: args{dbName: "_SomeDBname-"},
 			want: "somedbname",
 		},
+		{
+			name: "28 characters",
+			args: args{dbName: "a123456789012345678901234567"},
+			want: "a123456789012345678901234567",
+		},
+		{
+			name: "29 characters",
+			args: args{dbName: "a1234567890123456789012345678"},
+			want: "a1234567890123456789012345678",
+		},
+		{
+			name: "30 characters",
+			args: args{dbName: "a12345678901234567890123456789"},
+			want: "a12345678901234567890123456789",
+		},
+		{
+			name: "test prefix",
+			args: args{prefix: testDatabasePrefix, dbName: "SomeDBname"},
+			want: "t-somedbname",
+		},
+		{
+			name: "28 characters with test prefix",
+			args: args{prefix: testDatabasePrefix, dbName: "a123456789012345678901234567"},
+			want: "t-a123456789012345678901234567",
+		},
+		{
+			name: "29 characters with test prefix is truncated",
+			args: args{prefix: testDatabasePrefix, dbName: "a1234567890123456789012345678"},
+			want: "t-db1-90123456789012345678",
+		},
+		{
+			name: "30 characters with test prefix is truncated",
+			args: args{prefix: testDatabasePrefix, dbName: "a12345678901234567890123456789"},
+			want: "t-db1-01234567890123456789",
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 			sp := &SpannerContainer{}
-			if got := sp.validDatabaseName(tt.args.dbName); got != tt.want {
+			if got := sp.validDatabaseName(tt.args.prefix, tt.args.dbName); got != tt.want {
 				t.Errorf("Container.validDatabaseName() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func TestSpannerContainer_Pause(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	db, err := container.CreateTestDatabase(ctx, t.Name())
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateTestDatabase() error = %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	if err := container.Pause(ctx); err != nil {
+		t.Fatalf("SpannerContainer.Pause() error = %v", err)
+	}
+
+	state, err := container.State(ctx)
+	if err != nil {
+		t.Fatalf("container.State() error = %v", err)
+	}
+	if !state.Paused {
+		t.Errorf("container.State() = %v, want %v", state.Status, "paused")
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if _, err := db.QueryJSON(timeoutCtx, spanner.Statement{SQL: "SELECT 1"}); err == nil {
+		t.Errorf("SpannerDB.QueryJSON() error = nil, want error while paused")
+	}
+
+	if err := container.Unpause(ctx); err != nil {
+		t.Fatalf("SpannerContainer.Unpause() error = %v", err)
+	}
+
+	if _, err := db.QueryJSON(ctx, spanner.Statement{SQL: "SELECT 1"}); err != nil {
+		t.Errorf("SpannerDB.QueryJSON() error = %v after Unpause()", err)
+	}
+}
+
+func TestContainerExists(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	name := "testdb-exists-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	container, err := NewSpannerContainer(ctx, "latest", WithContainerName(name))
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	tests := []struct {
+		name string
+		cfg  *Config
+		want bool
+	}{
+		{name: "running", cfg: &Config{ContainerName: name}, want: true},
+		{name: "missing", cfg: &Config{ContainerName: name + "-missing"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ContainerExists(ctx, tt.cfg)
+			if err != nil {
+				t.Fatalf("ContainerExists() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ContainerExists() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpannerDB_Analyze(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	db, err := container.CreateTestDatabase(ctx, t.Name())
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateTestDatabase() error = %v", err)
+	}
+	db.Cleanup(t)
+
+	if err := db.MigrateUp("file://testdata/migrations"); err != nil {
+		t.Fatalf("SpannerDB.MigrateUp() error = %v", err)
+	}
+
+	if err := db.Analyze(ctx); err != nil {
+		t.Errorf("SpannerDB.Analyze() error = %v", err)
+	}
+}
+
+func TestSpannerContainer_CreateTestDatabase_concurrent(t *testing.T) {
+	t.Parallel()
+
+	const maxCreates = 2
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest", WithMaxConcurrentCreates(maxCreates))
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	// Track the CreateDatabase calls in flight, slowed down so unbounded creates would overlap
+	var inFlight, maxInFlight atomic.Int64
+	admin, err := database.NewDatabaseAdminClient(ctx, append(slices.Clip(container.opts), option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			if strings.HasSuffix(method, "/CreateDatabase") {
+				n := inFlight.Add(1)
+				defer inFlight.Add(-1)
+				for {
+					m := maxInFlight.Load()
+					if n <= m || maxInFlight.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(100 * time.Millisecond)
+			}
+
+			return invoker(ctx, method, req, reply, cc, opts...)
+		},
+	)))...)
+	if err != nil {
+		t.Fatalf("database.NewDatabaseAdminClient() error = %v", err)
+	}
+	_ = container.admin.Close()
+	container.admin = admin
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			db, err := container.CreateTestDatabase(ctx, fmt.Sprintf("%s-%d", t.Name(), i))
+			if err != nil {
+				errs <- err
+
+				return
+			}
+			db.Cleanup(t)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("SpannerContainer.CreateTestDatabase() error = %v", err)
+	}
+	if got := maxInFlight.Load(); got > maxCreates {
+		t.Errorf("SpannerContainer.CreateTestDatabase() ran %d creates concurrently, want at most %d", got, maxCreates)
+	}
+}
+
+func TestNewSpannerContainer_WithFiles(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest",
+		WithFiles(testcontainers.ContainerFile{
+			HostFilePath:      "testdata/migrations/000001_users.up.sql",
+			ContainerFilePath: "/config/users.sql",
+			FileMode:          0o644,
+		}),
+		WithTmpfs(map[string]string{"/scratch": "rw"}),
+	)
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	r, err := container.CopyFileFromContainer(ctx, "/config/users.sql")
+	if err != nil {
+		t.Fatalf("container.CopyFileFromContainer() error = %v", err)
+	}
+	defer r.Close()
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("io.ReadAll() error = %v", err)
+	}
+	want, err := os.ReadFile("testdata/migrations/000001_users.up.sql")
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("container file = %q, want %q", got, want)
+	}
+}
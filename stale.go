@@ -0,0 +1,74 @@
+package dbinitiator
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	"github.com/go-playground/errors/v5"
+	"google.golang.org/api/iterator"
+)
+
+// DropStaleDatabases drops the test databases in the instance that were created more than ttl
+// ago, such as those left behind by interrupted runs against a reused container, and returns
+// their names. Only databases named by CreateTestDatabase are considered, and databases kept
+// for failed tests (see WithKeepOnFailure) are left for inspection. Databases without a
+// creation time are never dropped.
+func (sp *SpannerContainer) DropStaleDatabases(ctx context.Context, ttl time.Duration) ([]string, error) {
+	cutoff := time.Now().Add(-ttl)
+
+	kept, err := keptDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	var dropped []string
+	iter := sp.admin.ListDatabases(ctx, &databasepb.ListDatabasesRequest{
+		Parent: fmt.Sprintf("projects/%s/instances/%s", sp.projectID, sp.instanceID),
+	})
+	for {
+		db, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return dropped, errors.Wrap(err, "database.DatabaseIterator.Next()")
+		}
+
+		if !strings.HasPrefix(path.Base(db.GetName()), testDatabasePrefix) || kept[db.GetName()] {
+			continue
+		}
+
+		created, ok := createTime(db)
+		if !ok {
+			sp.logger.Warn("keeping database without a creation time", "database", db.GetName())
+
+			continue
+		}
+		if created.After(cutoff) {
+			continue
+		}
+
+		if err := sp.admin.DropDatabase(ctx, &databasepb.DropDatabaseRequest{Database: db.GetName()}); err != nil {
+			return dropped, errors.Wrapf(err, "database.DatabaseAdminClient.DropDatabase(): %s", db.GetName())
+		}
+		dropped = append(dropped, path.Base(db.GetName()))
+		sp.logger.Info("dropped stale database", "database", db.GetName(), "created", created)
+	}
+
+	return dropped, nil
+}
+
+// createTime returns the creation time of db. A missing or zero creation time leaves the age
+// of the database unknown, which is reported as not ok so the database is not dropped.
+func createTime(db *databasepb.Database) (time.Time, bool) {
+	ts := db.GetCreateTime()
+	if ts == nil || (ts.GetSeconds() == 0 && ts.GetNanos() == 0) || !ts.IsValid() {
+		return time.Time{}, false
+	}
+
+	return ts.AsTime(), true
+}
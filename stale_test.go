@@ -0,0 +1,87 @@
+package dbinitiator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestSpannerContainer_DropStaleDatabases(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	oldDB, err := container.CreateTestDatabase(ctx, "old")
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateTestDatabase() error = %v", err)
+	}
+	defer oldDB.Close()
+
+	devDB, err := container.CreateDatabase(ctx, "dev")
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateDatabase() error = %v", err)
+	}
+	defer devDB.Close()
+
+	// Age the first databases past the TTL before creating a fresh one
+	const ttl = 2 * time.Second
+	time.Sleep(2 * ttl)
+
+	freshDB, err := container.CreateTestDatabase(ctx, "fresh")
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateTestDatabase() error = %v", err)
+	}
+	defer freshDB.Close()
+
+	dropped, err := container.DropStaleDatabases(ctx, ttl)
+	if err != nil {
+		t.Fatalf("SpannerContainer.DropStaleDatabases() error = %v", err)
+	}
+	if len(dropped) != 1 || dropped[0] != "t-old" {
+		t.Errorf("SpannerContainer.DropStaleDatabases() = %v, want [t-old]", dropped)
+	}
+
+	// The fresh database and the database not created by CreateTestDatabase survive
+	for _, db := range []*SpannerDB{freshDB, devDB} {
+		if err := db.Single().Query(ctx, spanner.Statement{SQL: "SELECT 1"}).Do(func(*spanner.Row) error { return nil }); err != nil {
+			t.Errorf("spanner.RowIterator.Do() error = %v, want %s to survive", err, db.DatabaseName())
+		}
+	}
+}
+
+func Test_createTime(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		db     *databasepb.Database
+		want   time.Time
+		wantOK bool
+	}{
+		{name: "set", db: &databasepb.Database{CreateTime: timestamppb.New(created)}, want: created, wantOK: true},
+		{name: "missing", db: &databasepb.Database{}},
+		{name: "zero", db: &databasepb.Database{CreateTime: &timestamppb.Timestamp{}}},
+		{name: "invalid", db: &databasepb.Database{CreateTime: &timestamppb.Timestamp{Nanos: -1}}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := createTime(tt.db)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("createTime() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
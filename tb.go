@@ -0,0 +1,21 @@
+package dbinitiator
+
+// TB is the part of testing.TB used by the test helpers, so the package does not import
+// testing outside of tests. *testing.T and *testing.B implement it.
+type TB interface {
+	Helper()
+	Name() string
+	Failed() bool
+	Cleanup(f func())
+	Logf(format string, args ...any)
+	Errorf(format string, args ...any)
+	Fatalf(format string, args ...any)
+	Skipf(format string, args ...any)
+}
+
+// BenchmarkTB is the part of *testing.B used by SpannerContainer.BenchmarkSetup
+type BenchmarkTB interface {
+	TB
+	StartTimer()
+	StopTimer()
+}
@@ -0,0 +1,56 @@
+package dbinitiator
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+)
+
+func TestSpannerContainer_CreateTenantDatabases(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	if _, err := container.CreateTenantDatabases(ctx, "broken", 2, "file://testdata/migration_error"); err == nil {
+		t.Errorf("SpannerContainer.CreateTenantDatabases() error = nil, want migration error")
+	}
+
+	tenants, err := container.CreateTenantDatabases(ctx, "tenant", 3, "file://testdata/migrations")
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateTenantDatabases() error = %v", err)
+	}
+	if len(tenants) != 3 {
+		t.Fatalf("SpannerContainer.CreateTenantDatabases() returned %d databases, want 3", len(tenants))
+	}
+	for _, db := range tenants {
+		db.Cleanup(t)
+	}
+
+	if _, err := tenants[0].Apply(ctx, []*spanner.Mutation{
+		spanner.Insert("Users", []string{"Id", "Username"}, []any{"1", "alice"}),
+	}); err != nil {
+		t.Fatalf("spanner.Client.Apply() error = %v", err)
+	}
+
+	for i, db := range tenants {
+		sizes, err := db.SizeReport(ctx)
+		if err != nil {
+			t.Fatalf("SpannerDB.SizeReport() error = %v", err)
+		}
+		want := int64(0)
+		if i == 0 {
+			want = 1
+		}
+		for _, s := range sizes {
+			if s.Table == "Users" && s.Rows != want {
+				t.Errorf("tenant %d Users rows = %d, want %d", i, s.Rows, want)
+			}
+		}
+	}
+}
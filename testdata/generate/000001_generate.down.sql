@@ -0,0 +1,7 @@
+DROP TABLE Orders;
+
+DROP TABLE Addresses;
+
+DROP INDEX Customers_Name;
+
+DROP TABLE Customers;
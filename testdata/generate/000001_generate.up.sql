@@ -0,0 +1,29 @@
+CREATE TABLE Customers (
+  CustomerId INT64 NOT NULL,
+  Name STRING(64) NOT NULL,
+  Email STRING(MAX),
+  Balance NUMERIC,
+  Tags ARRAY<STRING(16)>,
+  CreatedAt TIMESTAMP NOT NULL,
+) PRIMARY KEY(CustomerId);
+
+CREATE UNIQUE INDEX Customers_Name ON Customers(Name);
+
+CREATE TABLE Addresses (
+  CustomerId INT64 NOT NULL,
+  AddressId INT64 NOT NULL,
+  Street STRING(MAX) NOT NULL,
+  ValidFrom DATE,
+) PRIMARY KEY(CustomerId, AddressId),
+  INTERLEAVE IN PARENT Customers ON DELETE CASCADE;
+
+CREATE TABLE Orders (
+  OrderId INT64 NOT NULL,
+  CustomerId INT64 NOT NULL,
+  Quantity INT64 NOT NULL,
+  Price FLOAT64,
+  Paid BOOL NOT NULL,
+  Metadata JSON,
+  CONSTRAINT Orders_Quantity CHECK (Quantity > 0),
+  CONSTRAINT Orders_Customer FOREIGN KEY (CustomerId) REFERENCES Customers (CustomerId),
+) PRIMARY KEY(OrderId);
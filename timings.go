@@ -0,0 +1,142 @@
+package dbinitiator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Steps recorded in Timings
+const (
+	StepContainerStart = "container start"
+	StepCreateDatabase = "create database"
+	StepMigrateUp      = "migrate up"
+	StepMigrateDown    = "migrate down"
+)
+
+// Timings records how long test infrastructure setup steps take, so the overhead can be
+// quantified and tracked over time. A nil *Timings records nothing.
+type Timings struct {
+	mu    sync.Mutex
+	steps map[string]*StepTiming
+}
+
+// StepTiming is the accumulated timing of a setup step
+type StepTiming struct {
+	Count int
+	Total time.Duration
+	Max   time.Duration
+}
+
+// Avg returns the average duration of the step
+func (s StepTiming) Avg() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+
+	return s.Total / time.Duration(s.Count)
+}
+
+func newTimings() *Timings {
+	return &Timings{steps: make(map[string]*StepTiming)}
+}
+
+// record adds a run of step that started at start
+func (t *Timings) record(step string, start time.Time) {
+	if t == nil {
+		return
+	}
+	d := time.Since(start)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.steps[step]
+	if !ok {
+		s = &StepTiming{}
+		t.steps[step] = s
+	}
+	s.Count++
+	s.Total += d
+	if d > s.Max {
+		s.Max = d
+	}
+}
+
+// Steps returns a copy of the timings recorded so far, keyed by step
+func (t *Timings) Steps() map[string]StepTiming {
+	steps := make(map[string]StepTiming)
+	if t == nil {
+		return steps
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for name, s := range t.steps {
+		steps[name] = *s
+	}
+
+	return steps
+}
+
+// Summary returns a table of the recorded timings, suitable for printing at the end of a
+// test run (e.g. from TestMain).
+func (t *Timings) Summary() string {
+	steps := t.Steps()
+	names := make([]string, 0, len(steps))
+	for name := range steps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STEP\tCOUNT\tTOTAL\tAVG\tMAX")
+	for _, name := range names {
+		s := steps[name]
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", name, s.Count, s.Total.Round(time.Millisecond), s.Avg().Round(time.Millisecond), s.Max.Round(time.Millisecond))
+	}
+	_ = w.Flush()
+
+	return b.String()
+}
+
+// Timings returns the setup timings recorded for the container and its databases
+func (sp *SpannerContainer) Timings() *Timings {
+	return sp.timings
+}
+
+// BenchmarkSetup benchmarks creating a database and applying the migrations in sourceURL,
+// which is the per-test setup cost, n times. Pass b.N as n. Dropping the database is excluded
+// from the timing.
+func (sp *SpannerContainer) BenchmarkSetup(b BenchmarkTB, n int, sourceURL ...string) {
+	b.Helper()
+
+	ctx := context.Background()
+	for i := 0; i < n; i++ {
+		db, err := sp.CreateTestDatabase(ctx, fmt.Sprintf("%s-%d", b.Name(), i))
+		if err != nil {
+			b.Fatalf("SpannerContainer.CreateTestDatabase() error = %v", err)
+		}
+		if err := db.MigrateUp(sourceURL...); err != nil {
+			// Fatalf stops the benchmark, so drop the database first to not leak it
+			_ = db.DropDatabase(ctx)
+			_ = db.Close()
+			b.Fatalf("SpannerDB.MigrateUp() error = %v", err)
+		}
+
+		b.StopTimer()
+		if err := db.DropDatabase(ctx); err != nil {
+			b.Fatalf("SpannerDB.DropDatabase() error = %v", err)
+		}
+		if err := db.Close(); err != nil {
+			b.Fatalf("SpannerDB.Close() error = %v", err)
+		}
+		b.StartTimer()
+	}
+}
@@ -0,0 +1,115 @@
+package dbinitiator
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The helpers accept the testing types
+var (
+	_ TB          = (*testing.T)(nil)
+	_ BenchmarkTB = (*testing.B)(nil)
+)
+
+func TestTimings(t *testing.T) {
+	t.Parallel()
+
+	timings := newTimings()
+	timings.record(StepCreateDatabase, time.Now().Add(-3*time.Second))
+	timings.record(StepCreateDatabase, time.Now().Add(-1*time.Second))
+	timings.record(StepMigrateUp, time.Now().Add(-2*time.Second))
+
+	steps := timings.Steps()
+	if got := steps[StepCreateDatabase].Count; got != 2 {
+		t.Errorf("Timings.Steps()[%q].Count = %d, want 2", StepCreateDatabase, got)
+	}
+	if got := steps[StepCreateDatabase].Max; got < 3*time.Second {
+		t.Errorf("Timings.Steps()[%q].Max = %s, want at least 3s", StepCreateDatabase, got)
+	}
+	if got := steps[StepCreateDatabase].Avg(); got < 2*time.Second {
+		t.Errorf("Timings.Steps()[%q].Avg() = %s, want at least 2s", StepCreateDatabase, got)
+	}
+
+	summary := timings.Summary()
+	for _, want := range []string{"STEP", StepCreateDatabase, StepMigrateUp} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("Timings.Summary() does not contain %q:\n%s", want, summary)
+		}
+	}
+	if strings.Index(summary, StepCreateDatabase) > strings.Index(summary, StepMigrateUp) {
+		t.Errorf("Timings.Summary() steps are not sorted:\n%s", summary)
+	}
+}
+
+func TestTimings_nil(t *testing.T) {
+	t.Parallel()
+
+	var timings *Timings
+	timings.record(StepMigrateUp, time.Now())
+
+	if got := timings.Steps(); len(got) != 0 {
+		t.Errorf("Timings.Steps() = %v, want empty", got)
+	}
+}
+
+func BenchmarkSpannerContainer_BenchmarkSetup(b *testing.B) {
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		b.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	b.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	b.ResetTimer()
+	container.BenchmarkSetup(b, b.N, "file://testdata/migrations")
+}
+
+func TestSpannerContainer_BenchmarkSetup_migrateError(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	b := &benchmarkTB{recordingTB: recordingTB{TB: t}}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		container.BenchmarkSetup(b, 1, "file://testdata/missing")
+	}()
+	<-done
+	if !b.fatal {
+		t.Fatal("SpannerContainer.BenchmarkSetup() did not fail")
+	}
+
+	// A leaked database would be older than a TTL of zero
+	dropped, err := container.DropStaleDatabases(ctx, 0)
+	if err != nil {
+		t.Fatalf("SpannerContainer.DropStaleDatabases() error = %v", err)
+	}
+	if len(dropped) != 0 {
+		t.Errorf("SpannerContainer.BenchmarkSetup() leaked databases %v", dropped)
+	}
+}
+
+// benchmarkTB records fatal failures and stops the calling goroutine like testing.B, without
+// failing the test
+type benchmarkTB struct {
+	recordingTB
+	fatal bool
+}
+
+func (b *benchmarkTB) Fatalf(string, ...any) {
+	b.fatal = true
+	runtime.Goexit()
+}
+
+func (b *benchmarkTB) StartTimer() {}
+
+func (b *benchmarkTB) StopTimer() {}
@@ -0,0 +1,155 @@
+package dbinitiator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/errors/v5"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const (
+	defaultToxiproxyImage = "ghcr.io/shopify/toxiproxy:2.9.0"
+	toxiproxyAPIPort      = "8474/tcp"
+	toxiproxyProxyPort    = "8666/tcp"
+	toxiproxyProxyName    = "spanner"
+)
+
+// Toxiproxy controls a toxiproxy sidecar that all connections to the emulator are routed
+// through, allowing network faults to be injected. The proxy is shared by all databases in
+// the container: faults apply to every database, not to a single one, since all clients
+// reach the emulator through the same port. Tests injecting faults should use a container
+// of their own.
+type Toxiproxy struct {
+	testcontainers.Container
+	apiURL   string
+	endpoint string
+	client   *http.Client
+}
+
+// startToxiproxy starts a toxiproxy container on network proxying connections to upstream
+func startToxiproxy(ctx context.Context, image, network, upstream string) (*Toxiproxy, error) {
+	container, err := testcontainers.GenericContainer(ctx,
+		testcontainers.GenericContainerRequest{
+			Started: true,
+			ContainerRequest: testcontainers.ContainerRequest{
+				Image:        image,
+				ExposedPorts: []string{toxiproxyAPIPort, toxiproxyProxyPort},
+				Networks:     []string{network},
+				WaitingFor:   wait.ForHTTP("/version").WithPort(toxiproxyAPIPort),
+			},
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrap(withSentinel(ErrContainerStartFailed, err), "testcontainers.GenericContainer()")
+	}
+
+	apiEndpoint, err := container.PortEndpoint(ctx, toxiproxyAPIPort, "http")
+	if err != nil {
+		_ = container.Terminate(ctx)
+
+		return nil, errors.Wrapf(err, "failed to get endpoint for port %s", toxiproxyAPIPort)
+	}
+
+	proxyEndpoint, err := container.PortEndpoint(ctx, toxiproxyProxyPort, "")
+	if err != nil {
+		_ = container.Terminate(ctx)
+
+		return nil, errors.Wrapf(err, "failed to get endpoint for port %s", toxiproxyProxyPort)
+	}
+
+	tp := &Toxiproxy{
+		Container: container,
+		apiURL:    apiEndpoint,
+		endpoint:  proxyEndpoint,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+
+	if err := tp.post(ctx, "/proxies", map[string]any{
+		"name":     toxiproxyProxyName,
+		"listen":   "0.0.0.0:8666",
+		"upstream": upstream,
+		"enabled":  true,
+	}); err != nil {
+		_ = container.Terminate(ctx)
+
+		return nil, errors.Wrap(err, "failed to create proxy")
+	}
+
+	return tp, nil
+}
+
+// AddLatency delays all data passing through the proxy by latency
+func (tp *Toxiproxy) AddLatency(ctx context.Context, latency time.Duration) error {
+	return tp.addToxic(ctx, "latency", map[string]any{"latency": latency.Milliseconds()}, "downstream")
+}
+
+// LimitBandwidth limits the data passing through the proxy to rate KB/s
+func (tp *Toxiproxy) LimitBandwidth(ctx context.Context, rate int) error {
+	return tp.addToxic(ctx, "bandwidth", map[string]any{"rate": rate}, "upstream", "downstream")
+}
+
+// DropConnections makes the proxy reset connections with a TCP RST when data next flows
+// through them, so requests fail with connection errors until Reset is called. Idle
+// connections are not closed until they are used.
+func (tp *Toxiproxy) DropConnections(ctx context.Context) error {
+	return tp.addToxic(ctx, "reset_peer", map[string]any{"timeout": 0}, "downstream")
+}
+
+// Reset removes all faults added to the proxy
+func (tp *Toxiproxy) Reset(ctx context.Context) error {
+	return tp.post(ctx, "/reset", nil)
+}
+
+func (tp *Toxiproxy) addToxic(ctx context.Context, toxicType string, attributes map[string]any, streams ...string) error {
+	for _, stream := range streams {
+		if err := tp.post(ctx, fmt.Sprintf("/proxies/%s/toxics", toxiproxyProxyName), map[string]any{
+			"name":       fmt.Sprintf("%s_%s_%d", toxicType, stream, time.Now().UnixNano()),
+			"type":       toxicType,
+			"stream":     stream,
+			"toxicity":   1.0,
+			"attributes": attributes,
+		}); err != nil {
+			return errors.Wrapf(err, "failed to add %s toxic", toxicType)
+		}
+	}
+
+	return nil
+}
+
+func (tp *Toxiproxy) post(ctx context.Context, path string, body any) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(err, "json.Marshal()")
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tp.apiURL+path, r)
+	if err != nil {
+		return errors.Wrap(err, "http.NewRequestWithContext()")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := tp.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "http.Client.Do()")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		msg, _ := io.ReadAll(resp.Body)
+
+		return errors.Newf("toxiproxy: POST %s: %s: %s", path, resp.Status, bytes.TrimSpace(msg))
+	}
+
+	return nil
+}
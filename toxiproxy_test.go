@@ -0,0 +1,91 @@
+package dbinitiator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+)
+
+func TestToxiproxy(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest", WithToxiproxy())
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	proxy := container.Proxy()
+	if proxy == nil {
+		t.Fatalf("SpannerContainer.Proxy() = nil")
+	}
+
+	db, err := container.CreateTestDatabase(ctx, t.Name())
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateTestDatabase() error = %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	query := func() (time.Duration, error) {
+		start := time.Now()
+		_, err := db.QueryJSON(ctx, spanner.Statement{SQL: "SELECT 1"})
+
+		return time.Since(start), err
+	}
+
+	if _, err := query(); err != nil {
+		t.Fatalf("SpannerDB.QueryJSON() error = %v", err)
+	}
+
+	latency := 500 * time.Millisecond
+	if err := proxy.AddLatency(ctx, latency); err != nil {
+		t.Fatalf("Toxiproxy.AddLatency() error = %v", err)
+	}
+	if d, err := query(); err != nil {
+		t.Fatalf("SpannerDB.QueryJSON() error = %v", err)
+	} else if d < latency {
+		t.Errorf("SpannerDB.QueryJSON() took %s, want at least %s", d, latency)
+	}
+
+	if err := proxy.Reset(ctx); err != nil {
+		t.Fatalf("Toxiproxy.Reset() error = %v", err)
+	}
+	if d, err := query(); err != nil {
+		t.Fatalf("SpannerDB.QueryJSON() error = %v", err)
+	} else if d >= latency {
+		t.Errorf("SpannerDB.QueryJSON() took %s after Reset(), want less than %s", d, latency)
+	}
+
+	// A 3KB result at 1KB/s takes seconds
+	if err := proxy.LimitBandwidth(ctx, 1); err != nil {
+		t.Fatalf("Toxiproxy.LimitBandwidth() error = %v", err)
+	}
+	start := time.Now()
+	if _, err := db.QueryJSON(ctx, spanner.Statement{SQL: "SELECT REPEAT('x', 3000)"}); err != nil {
+		t.Fatalf("SpannerDB.QueryJSON() error = %v", err)
+	}
+	if d, want := time.Since(start), 2*time.Second; d < want {
+		t.Errorf("SpannerDB.QueryJSON() took %s with limited bandwidth, want at least %s", d, want)
+	}
+	if err := proxy.Reset(ctx); err != nil {
+		t.Fatalf("Toxiproxy.Reset() error = %v", err)
+	}
+
+	if err := proxy.DropConnections(ctx); err != nil {
+		t.Fatalf("Toxiproxy.DropConnections() error = %v", err)
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	if _, err := db.QueryJSON(timeoutCtx, spanner.Statement{SQL: "SELECT 1"}); err == nil {
+		t.Errorf("SpannerDB.QueryJSON() error = nil, want error while connections are dropped")
+	}
+	if err := proxy.Reset(ctx); err != nil {
+		t.Fatalf("Toxiproxy.Reset() error = %v", err)
+	}
+	if _, err := query(); err != nil {
+		t.Errorf("SpannerDB.QueryJSON() error = %v after Reset()", err)
+	}
+}